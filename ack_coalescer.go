@@ -0,0 +1,257 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default EnableAckCoalescing settings.
+const (
+	DefaultAckCoalescingMaxPending = 100
+	DefaultAckCoalescingInterval   = 10 * time.Millisecond
+)
+
+/*
+EnableAckCoalescing makes Channel.Ack, and so Delivery.Ack, hold
+acknowledgements back and send them as a single basic.ack with multiple set.
+
+The channel keeps track of the deliveries it hands to consumers that are not
+in automatic acknowledgement mode, and of those received with Channel.Get.  An
+acknowledgement is held until it can be covered by a multiple acknowledgement
+of a later delivery without also acknowledging a delivery that is still being
+processed.  This keeps coalescing correct when concurrent workers acknowledge
+deliveries out of order.
+
+Held acknowledgements are sent once maxPending of them are held, interval after
+the first of them was held, and before any Nack, Reject or Close on the channel.
+An acknowledgement that leaves an earlier delivery unacknowledged also sends
+the acknowledgements that can already be coalesced.  Acknowledgements that
+cannot be coalesced when a flush is due are sent one by one.  maxPending and
+interval default to DefaultAckCoalescingMaxPending and
+DefaultAckCoalescingInterval when not positive.
+
+As Channel.Ack returns before the acknowledgement is sent, errors sending held
+acknowledgements once the interval has passed are logged, and returned by the
+next call to Channel.Ack.  Channel.Ack returns ErrClosed once the channel is
+closed, as held acknowledgements can no longer be sent.  Enable coalescing before consuming:
+deliveries received earlier are not tracked and may be acknowledged by a
+coalesced acknowledgement.  Held acknowledgements are dropped when the channel
+is recovered, as delivery tags start over on the new channel.
+*/
+func (ch *Channel) EnableAckCoalescing(maxPending int, interval time.Duration) {
+	if maxPending <= 0 {
+		maxPending = DefaultAckCoalescingMaxPending
+	}
+	if interval <= 0 {
+		interval = DefaultAckCoalescingInterval
+	}
+
+	ch.acks.Store(&ackCoalescer{
+		ch:          ch,
+		maxPending:  maxPending,
+		interval:    interval,
+		outstanding: make(map[uint64]struct{}),
+		pending:     make(map[uint64]struct{}),
+	})
+}
+
+// ackCoalescer holds the acknowledgements of a channel back.  Frames are only
+// sent while holding the channel's mutex and then the coalescer's, so they are
+// never reordered.
+type ackCoalescer struct {
+	ch         *Channel
+	maxPending int
+	interval   time.Duration
+
+	m           sync.Mutex
+	outstanding map[uint64]struct{} // tracked deliveries not settled yet
+	pending     map[uint64]struct{} // acknowledgements held back
+	timer       *time.Timer
+	err         error // error of the last flush after the interval
+}
+
+// delivered tracks a delivery that has to be settled.
+func (a *ackCoalescer) delivered(tag uint64) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.outstanding[tag] = struct{}{}
+}
+
+// ack holds an acknowledgement back.  The caller must hold the channel's
+// mutex.
+func (a *ackCoalescer) ack(tag uint64, multiple bool) error {
+	if a.ch.IsClosed() {
+		return ErrClosed
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if err := a.err; err != nil {
+		a.err = nil
+		return err
+	}
+
+	a.settle(tag, multiple)
+	a.pending[tag] = struct{}{}
+
+	if len(a.pending) >= a.maxPending {
+		return a.flush(true)
+	}
+
+	if tag > a.lowestOutstanding() {
+		if err := a.flush(false); err != nil {
+			return err
+		}
+	}
+
+	if len(a.pending) > 0 && a.timer == nil {
+		a.timer = time.AfterFunc(a.interval, a.tick)
+	}
+	return nil
+}
+
+// beforeSettle stops tracking deliveries settled by a nack or reject, after
+// sending the acknowledgements held back so that a multiple nack does not cover
+// them.  The caller must hold the channel's mutex.
+func (a *ackCoalescer) beforeSettle(tag uint64, multiple bool) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	err := a.flush(true)
+	a.settle(tag, multiple)
+	return err
+}
+
+// flushAll sends every acknowledgement held back.
+func (a *ackCoalescer) flushAll() error {
+	a.ch.m.Lock()
+	defer a.ch.m.Unlock()
+	a.m.Lock()
+	defer a.m.Unlock()
+	return a.flush(true)
+}
+
+func (a *ackCoalescer) tick() {
+	if err := a.flushAll(); err != nil {
+		Logger.Printf("error sending coalesced acknowledgements, channel id: %d error: %+v", a.ch.id, err)
+		a.m.Lock()
+		a.err = err
+		a.m.Unlock()
+	}
+}
+
+// reset forgets the deliveries and acknowledgements of a previous channel.
+func (a *ackCoalescer) reset() {
+	a.m.Lock()
+	defer a.m.Unlock()
+	a.outstanding = make(map[uint64]struct{})
+	a.pending = make(map[uint64]struct{})
+	a.err = nil
+	a.stopTimer()
+}
+
+func (a *ackCoalescer) settle(tag uint64, multiple bool) {
+	if multiple {
+		for t := range a.outstanding {
+			if t <= tag {
+				delete(a.outstanding, t)
+			}
+		}
+	}
+	delete(a.outstanding, tag)
+}
+
+func (a *ackCoalescer) lowestOutstanding() uint64 {
+	var lowest uint64 = math.MaxUint64
+	for t := range a.outstanding {
+		if t < lowest {
+			lowest = t
+		}
+	}
+	return lowest
+}
+
+func (a *ackCoalescer) stopTimer() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+}
+
+// flush sends the acknowledgements held back below the lowest outstanding
+// delivery as a single multiple acknowledgement, and when force is set, the
+// others one by one.  The caller must hold both mutexes.
+func (a *ackCoalescer) flush(force bool) error {
+	lowest := a.lowestOutstanding()
+
+	var (
+		covered uint64
+		rest    []uint64
+	)
+	for t := range a.pending {
+		if t < lowest {
+			if t > covered {
+				covered = t
+			}
+		} else {
+			rest = append(rest, t)
+		}
+	}
+
+	if covered > 0 {
+		for t := range a.pending {
+			if t <= covered {
+				delete(a.pending, t)
+			}
+		}
+		if err := a.ch.send(&basicAck{DeliveryTag: covered, Multiple: true}); err != nil {
+			a.pending = make(map[uint64]struct{})
+			a.stopTimer()
+			return err
+		}
+	}
+
+	if force {
+		sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+		for _, t := range rest {
+			delete(a.pending, t)
+			if err := a.ch.send(&basicAck{DeliveryTag: t}); err != nil {
+				a.pending = make(map[uint64]struct{})
+				a.stopTimer()
+				return err
+			}
+		}
+	}
+
+	if len(a.pending) == 0 {
+		a.stopTimer()
+	}
+	return nil
+}
+
+// dispatchDelivery hands a delivery to its consumer, tracking it first when it
+// has to be acknowledged and acknowledgements are coalesced.  A tracked
+// delivery whose consumer is gone is requeued.
+func (ch *Channel) dispatchDelivery(consumerTag string, d *Delivery) bool {
+	a := ch.acks.Load()
+	if a == nil || ch.consumers.isAutoAck(consumerTag) {
+		return ch.consumers.send(consumerTag, d)
+	}
+
+	a.delivered(d.DeliveryTag)
+	if !ch.consumers.send(consumerTag, d) {
+		// Requeue the delivery nobody will see, rather than letting a
+		// multiple acknowledgement cover it.
+		if err := ch.Nack(d.DeliveryTag, false, true); err != nil {
+			Logger.Printf("error requeueing delivery %d for missing consumer %s: %+v", d.DeliveryTag, consumerTag, err)
+		}
+		return false
+	}
+	return true
+}
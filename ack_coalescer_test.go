@@ -0,0 +1,205 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"testing"
+	"time"
+)
+
+// serveDeliveries subscribes tag and delivers n messages to it.
+func serveDeliveries(srv *server, tag string, n int) {
+	srv.recv(1, &basicConsume{})
+	srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+	for i := 1; i <= n; i++ {
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: uint64(i)})
+	}
+}
+
+func receiveDeliveries(t *testing.T, deliveries <-chan Delivery, n int) []Delivery {
+	t.Helper()
+
+	var ds []Delivery
+	for i := 0; i < n; i++ {
+		select {
+		case d := <-deliveries:
+			ds = append(ds, d)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for delivery %d", i+1)
+		}
+	}
+	return ds
+}
+
+func TestAckCoalescingSendsInOrderAcksAsMultiple(t *testing.T) {
+	const tag = "coalesced"
+
+	acks := make(chan *basicAck, 2)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		serveDeliveries(srv, tag, 5)
+
+		// Count trigger.
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acks <- ack
+
+		// Interval trigger.
+		ack = &basicAck{}
+		srv.recv(1, ack)
+		acks <- ack
+	})
+	ch.EnableAckCoalescing(3, 50*time.Millisecond)
+
+	deliveries, err := ch.Consume("q", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	for _, d := range receiveDeliveries(t, deliveries, 5) {
+		if err := d.Ack(false); err != nil {
+			t.Fatalf("unexpected ack error: %v", err)
+		}
+	}
+
+	if ack := <-acks; ack.DeliveryTag != 3 || !ack.Multiple {
+		t.Errorf("expected a multiple ack of delivery 3 once 3 acks were held, got: %+v", ack)
+	}
+	if ack := <-acks; ack.DeliveryTag != 5 || !ack.Multiple {
+		t.Errorf("expected a multiple ack of delivery 5 after the interval, got: %+v", ack)
+	}
+}
+
+func TestAckCoalescingNeverCoversUnackedDeliveries(t *testing.T) {
+	const tag = "coalesced"
+
+	type settled struct {
+		ack2, ack3 *basicAck
+		nack       *basicNack
+	}
+	results := make(chan settled, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		serveDeliveries(srv, tag, 3)
+
+		// The acks held back for deliveries 2 and 3 cannot be coalesced
+		// while delivery 1 is unsettled, and are flushed before the nack.
+		r := settled{ack2: &basicAck{}, ack3: &basicAck{}, nack: &basicNack{}}
+		srv.recv(1, r.ack2)
+		srv.recv(1, r.ack3)
+		srv.recv(1, r.nack)
+		results <- r
+	})
+	ch.EnableAckCoalescing(10, time.Hour)
+
+	deliveries, err := ch.Consume("q", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	ds := receiveDeliveries(t, deliveries, 3)
+	if err := ds[2].Ack(false); err != nil {
+		t.Fatalf("unexpected ack error: %v", err)
+	}
+	if err := ds[1].Ack(false); err != nil {
+		t.Fatalf("unexpected ack error: %v", err)
+	}
+	if err := ds[0].Nack(false, true); err != nil {
+		t.Fatalf("unexpected nack error: %v", err)
+	}
+
+	r := <-results
+	if r.ack2.DeliveryTag != 2 || r.ack2.Multiple {
+		t.Errorf("expected a single ack of delivery 2, got: %+v", r.ack2)
+	}
+	if r.ack3.DeliveryTag != 3 || r.ack3.Multiple {
+		t.Errorf("expected a single ack of delivery 3, got: %+v", r.ack3)
+	}
+	if r.nack.DeliveryTag != 1 || r.nack.Multiple {
+		t.Errorf("expected delivery 1 to be nacked, got: %+v", r.nack)
+	}
+}
+
+func TestAckCoalescingFlushesBeforeClose(t *testing.T) {
+	const tag = "coalesced"
+
+	acks := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		serveDeliveries(srv, tag, 2)
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acks <- ack
+
+		srv.recv(1, &channelClose{})
+		srv.send(1, &channelCloseOk{})
+	})
+	ch.EnableAckCoalescing(10, time.Hour)
+
+	deliveries, err := ch.Consume("q", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	for _, d := range receiveDeliveries(t, deliveries, 2) {
+		if err := d.Ack(false); err != nil {
+			t.Fatalf("unexpected ack error: %v", err)
+		}
+	}
+
+	if err := ch.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if ack := <-acks; ack.DeliveryTag != 2 || !ack.Multiple {
+		t.Errorf("expected a multiple ack of delivery 2 before closing, got: %+v", ack)
+	}
+	if err := ch.Ack(3, false); err != ErrClosed {
+		t.Errorf("expected ErrClosed acknowledging on the closed channel, got: %v", err)
+	}
+}
+
+func TestAckCoalescingRequeuesDeliveriesForMissingConsumers(t *testing.T) {
+	const tag = "coalesced"
+
+	frames := make(chan message, 2)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+		// Delivered to a consumer cancelled meanwhile.
+		srv.send(1, &basicDeliver{ConsumerTag: "cancelled", DeliveryTag: 2, Body: []byte("lost")})
+
+		nack := &basicNack{}
+		srv.recv(1, nack)
+		frames <- nack
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 3, Body: []byte("last")})
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		frames <- ack
+	})
+	ch.EnableAckCoalescing(2, time.Minute)
+
+	deliveries, err := ch.Consume("q", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	for _, d := range receiveDeliveries(t, deliveries, 2) {
+		if err := d.Ack(false); err != nil {
+			t.Fatalf("unexpected ack error: %v", err)
+		}
+	}
+
+	if nack, ok := (<-frames).(*basicNack); !ok || nack.DeliveryTag != 2 || nack.Multiple || !nack.Requeue {
+		t.Errorf("expected the delivery without consumer to be requeued before any ack, got: %+v", nack)
+	}
+	if ack := (<-frames).(*basicAck); ack.DeliveryTag != 3 {
+		t.Errorf("expected the acks to be flushed, got: %+v", ack)
+	}
+}
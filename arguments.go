@@ -0,0 +1,364 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidArguments is wrapped by the errors returned when typed queue or
+// exchange arguments do not validate.
+var ErrInvalidArguments = errors.New("amqp: invalid arguments")
+
+func invalidArgument(arg, format string, a ...any) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidArguments, arg, fmt.Sprintf(format, a...))
+}
+
+// QueueArgs produces the arguments of a queue declaration.  It is implemented
+// by ClassicQueueArgs, QuorumQueueArgs and StreamQueueArgs.
+type QueueArgs interface {
+	// Table returns the validated arguments.
+	Table() (Table, error)
+}
+
+// DeadLetter routes the messages dead-lettered from a queue.
+type DeadLetter struct {
+	// Exchange the messages are republished to.  Empty is the default
+	// exchange.
+	Exchange string
+	// RoutingKey replaces the routing key of the messages when not empty.
+	RoutingKey string
+}
+
+/*
+ClassicQueueArgs are the arguments of a classic queue.  Zero fields are not
+sent.  Extra holds any other argument; it must not repeat an argument set by a
+field.
+
+	args := amqp.ClassicQueueArgs{
+		MaxLength:   1000,
+		Overflow:    amqp.QueueOverflowRejectPublish,
+		MaxPriority: 10,
+	}
+	q, err := ch.QueueDeclareWithArguments("tasks", true, false, false, false, args)
+*/
+type ClassicQueueArgs struct {
+	MaxLength            int64
+	MaxLengthBytes       int64
+	Overflow             string // QueueOverflowDropHead, QueueOverflowRejectPublish or QueueOverflowRejectPublishDLX
+	MessageTTL           time.Duration
+	Expires              time.Duration // how long the queue may be unused before it is deleted
+	DeadLetter           *DeadLetter
+	SingleActiveConsumer bool
+	MaxPriority          uint8
+	Version              int // 1 or 2
+	Extra                Table
+}
+
+// Table returns the validated arguments of the queue.
+func (a ClassicQueueArgs) Table() (Table, error) {
+	b := newArgsBuilder(QueueTypeClassic)
+	b.maxLength(a.MaxLength, a.MaxLengthBytes)
+	b.overflow(a.Overflow, QueueOverflowDropHead, QueueOverflowRejectPublish, QueueOverflowRejectPublishDLX)
+	b.ttl(a.MessageTTL, a.Expires)
+	b.deadLetter(a.DeadLetter)
+	if a.SingleActiveConsumer {
+		b.set(SingleActiveConsumerArg, true)
+	}
+	if a.MaxPriority > 0 {
+		b.set(QueueMaxPriorityArg, int64(a.MaxPriority))
+	}
+	switch a.Version {
+	case 0:
+	case 1, 2:
+		b.set(QueueVersionArg, int64(a.Version))
+	default:
+		b.fail(invalidArgument(QueueVersionArg, "version must be 1 or 2, got %d", a.Version))
+	}
+	return b.build(a.Extra, StreamMaxAgeArg, StreamMaxSegmentSizeBytesArg, StreamInitialClusterSizeArg,
+		QueueDeadLetterStrategyArg, QueueDeliveryLimitArg, QueueInitialGroupSizeArg)
+}
+
+// QuorumQueueArgs are the arguments of a quorum queue.  Zero fields are not
+// sent.  Extra holds any other argument; it must not repeat an argument set by a
+// field nor set an argument quorum queues do not support, such as priorities.
+type QuorumQueueArgs struct {
+	MaxLength            int64
+	MaxLengthBytes       int64
+	Overflow             string // QueueOverflowDropHead or QueueOverflowRejectPublish
+	MessageTTL           time.Duration
+	Expires              time.Duration // how long the queue may be unused before it is deleted
+	DeadLetter           *DeadLetter
+	SingleActiveConsumer bool
+
+	// DeadLetterStrategy is QueueDeadLetterAtMostOnce or
+	// QueueDeadLetterAtLeastOnce, which requires a DeadLetter and the
+	// QueueOverflowRejectPublish overflow.
+	DeadLetterStrategy string
+
+	// DeliveryLimit is the number of times a message may be returned to the
+	// queue before it is dead-lettered or dropped.
+	DeliveryLimit int64
+
+	// InitialGroupSize is the number of replicas the queue starts with.
+	InitialGroupSize int
+
+	Extra Table
+}
+
+// Table returns the validated arguments of the queue.
+func (a QuorumQueueArgs) Table() (Table, error) {
+	b := newArgsBuilder(QueueTypeQuorum)
+	b.maxLength(a.MaxLength, a.MaxLengthBytes)
+	b.overflow(a.Overflow, QueueOverflowDropHead, QueueOverflowRejectPublish)
+	b.ttl(a.MessageTTL, a.Expires)
+	b.deadLetter(a.DeadLetter)
+	if a.SingleActiveConsumer {
+		b.set(SingleActiveConsumerArg, true)
+	}
+	switch a.DeadLetterStrategy {
+	case "":
+	case QueueDeadLetterAtMostOnce:
+		b.set(QueueDeadLetterStrategyArg, a.DeadLetterStrategy)
+	case QueueDeadLetterAtLeastOnce:
+		if a.DeadLetter == nil {
+			b.fail(invalidArgument(QueueDeadLetterStrategyArg, "%s requires a dead letter exchange", a.DeadLetterStrategy))
+		}
+		if a.Overflow != QueueOverflowRejectPublish {
+			b.fail(invalidArgument(QueueDeadLetterStrategyArg, "%s requires the %s overflow", a.DeadLetterStrategy, QueueOverflowRejectPublish))
+		}
+		b.set(QueueDeadLetterStrategyArg, a.DeadLetterStrategy)
+	default:
+		b.fail(invalidArgument(QueueDeadLetterStrategyArg, "unknown strategy %q", a.DeadLetterStrategy))
+	}
+	b.positive(QueueDeliveryLimitArg, a.DeliveryLimit)
+	b.positive(QueueInitialGroupSizeArg, int64(a.InitialGroupSize))
+	return b.build(a.Extra, QueueMaxPriorityArg, QueueVersionArg, "x-queue-mode",
+		StreamMaxAgeArg, StreamMaxSegmentSizeBytesArg, StreamInitialClusterSizeArg)
+}
+
+// StreamQueueArgs are the arguments of a stream.  Zero fields are not sent.
+// Extra holds any other argument; it must not repeat an argument set by a field
+// nor set an argument streams do not support, such as a message TTL.
+type StreamQueueArgs struct {
+	MaxLengthBytes int64
+
+	// MaxAge is the retention of the stream, in whole seconds.
+	MaxAge time.Duration
+
+	MaxSegmentSizeBytes  int64
+	InitialClusterSize   int
+	SingleActiveConsumer bool
+	Extra                Table
+}
+
+// Table returns the validated arguments of the stream.
+func (a StreamQueueArgs) Table() (Table, error) {
+	b := newArgsBuilder(QueueTypeStream)
+	b.positive(StreamMaxLenBytesArg, a.MaxLengthBytes)
+	if a.MaxAge != 0 {
+		if a.MaxAge < time.Second || a.MaxAge%time.Second != 0 {
+			b.fail(invalidArgument(StreamMaxAgeArg, "max age must be a whole number of seconds, got %s", a.MaxAge))
+		}
+		b.set(StreamMaxAgeArg, fmt.Sprintf("%ds", a.MaxAge/time.Second))
+	}
+	b.positive(StreamMaxSegmentSizeBytesArg, a.MaxSegmentSizeBytes)
+	b.positive(StreamInitialClusterSizeArg, int64(a.InitialClusterSize))
+	if a.SingleActiveConsumer {
+		b.set(SingleActiveConsumerArg, true)
+	}
+	return b.build(a.Extra, QueueMaxPriorityArg, QueueVersionArg, "x-queue-mode",
+		QueueMaxLenArg, QueueOverflowArg, QueueMessageTTLArg, QueueTTLArg,
+		QueueDeadLetterExchangeArg, QueueDeadLetterRoutingKeyArg, QueueDeadLetterStrategyArg,
+		QueueDeliveryLimitArg, QueueInitialGroupSizeArg)
+}
+
+// Exchange types provided by plugins.
+const (
+	// ExchangeDelayedMessage is the type of the exchanges of the
+	// rabbitmq_delayed_message_exchange plugin, which route messages after
+	// the delay set in their "x-delay" header.
+	ExchangeDelayedMessage = "x-delayed-message"
+)
+
+// Exchange argument keys.
+const (
+	// ExchangeDelayedTypeArg is the type of routing of an
+	// ExchangeDelayedMessage exchange.
+	ExchangeDelayedTypeArg = "x-delayed-type"
+	// AlternateExchangeArg is the exchange receiving the messages the
+	// exchange cannot route.
+	AlternateExchangeArg = "alternate-exchange"
+)
+
+/*
+ExchangeArgs are the type and arguments of an exchange declaration.  Extra holds
+any other argument; it must not repeat an argument set by a field.
+
+	args := amqp.ExchangeArgs{Kind: amqp.ExchangeDelayedMessage, DelayedType: amqp.ExchangeTopic}
+	err := ch.ExchangeDeclareWithArguments("delayed", true, false, false, false, args)
+*/
+type ExchangeArgs struct {
+	Kind string
+
+	// DelayedType is the type of routing of an ExchangeDelayedMessage
+	// exchange, which requires it.
+	DelayedType string
+
+	AlternateExchange string
+	Extra             Table
+}
+
+// Table returns the validated arguments of the exchange.
+func (a ExchangeArgs) Table() (Table, error) {
+	b := &argsBuilder{t: make(Table)}
+	if a.Kind == "" {
+		b.fail(invalidArgument("kind", "exchange type is required"))
+	}
+	switch {
+	case a.Kind == ExchangeDelayedMessage:
+		switch a.DelayedType {
+		case "":
+			b.fail(invalidArgument(ExchangeDelayedTypeArg, "required by %s exchanges", ExchangeDelayedMessage))
+		case ExchangeDelayedMessage:
+			b.fail(invalidArgument(ExchangeDelayedTypeArg, "cannot be %s", ExchangeDelayedMessage))
+		default:
+			b.set(ExchangeDelayedTypeArg, a.DelayedType)
+		}
+	case a.DelayedType != "":
+		b.fail(invalidArgument(ExchangeDelayedTypeArg, "only supported by %s exchanges", ExchangeDelayedMessage))
+	}
+	if a.AlternateExchange != "" {
+		b.set(AlternateExchangeArg, a.AlternateExchange)
+	}
+	return b.build(a.Extra)
+}
+
+// argsBuilder collects arguments and the first validation error.
+type argsBuilder struct {
+	t   Table
+	err error
+}
+
+func newArgsBuilder(queueType string) *argsBuilder {
+	return &argsBuilder{t: Table{QueueTypeArg: queueType}}
+}
+
+func (b *argsBuilder) set(arg string, v any) {
+	b.t[arg] = v
+}
+
+func (b *argsBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *argsBuilder) positive(arg string, v int64) {
+	switch {
+	case v < 0:
+		b.fail(invalidArgument(arg, "must not be negative, got %d", v))
+	case v > 0:
+		b.set(arg, v)
+	}
+}
+
+func (b *argsBuilder) maxLength(messages, bytes int64) {
+	b.positive(QueueMaxLenArg, messages)
+	b.positive(QueueMaxLenBytesArg, bytes)
+}
+
+func (b *argsBuilder) overflow(overflow string, supported ...string) {
+	if overflow == "" {
+		return
+	}
+	for _, s := range supported {
+		if overflow == s {
+			b.set(QueueOverflowArg, overflow)
+			return
+		}
+	}
+	b.fail(invalidArgument(QueueOverflowArg, "%q is not supported by %s queues", overflow, b.t[QueueTypeArg]))
+}
+
+func (b *argsBuilder) ttl(messageTTL, expires time.Duration) {
+	if messageTTL > 0 && messageTTL < time.Millisecond {
+		b.fail(invalidArgument(QueueMessageTTLArg, "must be at least 1ms, got %s", messageTTL))
+	}
+	b.positive(QueueMessageTTLArg, messageTTL.Milliseconds())
+	if expires != 0 && expires < time.Millisecond {
+		b.fail(invalidArgument(QueueTTLArg, "must be at least 1ms, got %s", expires))
+	}
+	b.positive(QueueTTLArg, expires.Milliseconds())
+}
+
+func (b *argsBuilder) deadLetter(dl *DeadLetter) {
+	if dl == nil {
+		return
+	}
+	b.set(QueueDeadLetterExchangeArg, dl.Exchange)
+	if dl.RoutingKey != "" {
+		b.set(QueueDeadLetterRoutingKeyArg, dl.RoutingKey)
+	}
+}
+
+// build merges extra into the arguments, rejecting arguments already set and
+// the unsupported ones.
+func (b *argsBuilder) build(extra Table, unsupported ...string) (Table, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := extra.Validate(); err != nil {
+		return nil, err
+	}
+
+	for k, v := range extra {
+		if _, ok := b.t[k]; ok {
+			return nil, invalidArgument(k, "set both by a field and in Extra")
+		}
+		for _, u := range unsupported {
+			if k == u {
+				return nil, invalidArgument(k, "not supported by %s queues", b.t[QueueTypeArg])
+			}
+		}
+		b.t[k] = v
+	}
+	return b.t, nil
+}
+
+/*
+QueueDeclareWithArguments is like QueueDeclare with typed arguments, which are
+validated before anything is sent to the server.  Quorum queues and streams
+must be durable, and can be neither exclusive nor auto-deleted.
+
+The queue is recorded for topology recovery as with QueueDeclare.
+*/
+func (ch *Channel) QueueDeclareWithArguments(name string, durable, autoDelete, exclusive, noWait bool, args QueueArgs) (Queue, error) {
+	table, err := args.Table()
+	if err != nil {
+		return Queue{}, err
+	}
+
+	if kind := table[QueueTypeArg]; kind == QueueTypeQuorum || kind == QueueTypeStream {
+		if !durable || autoDelete || exclusive {
+			return Queue{}, invalidArgument(QueueTypeArg, "%s queues must be durable, not auto-deleted and not exclusive", kind)
+		}
+	}
+
+	return ch.QueueDeclare(name, durable, autoDelete, exclusive, noWait, table)
+}
+
+// ExchangeDeclareWithArguments is like ExchangeDeclare with the exchange type
+// and arguments taken from args, which are validated before anything is sent
+// to the server.  The exchange is recorded for topology recovery as with
+// ExchangeDeclare.
+func (ch *Channel) ExchangeDeclareWithArguments(name string, durable, autoDelete, internal, noWait bool, args ExchangeArgs) error {
+	table, err := args.Table()
+	if err != nil {
+		return err
+	}
+	return ch.ExchangeDeclare(name, args.Kind, durable, autoDelete, internal, noWait, table)
+}
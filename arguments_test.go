@@ -0,0 +1,198 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueueArgsTable(t *testing.T) {
+	tests := []struct {
+		name string
+		args QueueArgs
+		want Table
+	}{
+		{
+			name: "classic",
+			args: ClassicQueueArgs{
+				MaxLength:   100,
+				Overflow:    QueueOverflowRejectPublishDLX,
+				MessageTTL:  time.Minute,
+				Expires:     30 * time.Minute,
+				DeadLetter:  &DeadLetter{Exchange: "dlx"},
+				MaxPriority: 10,
+				Version:     2,
+				Extra:       Table{"x-custom": "v"},
+			},
+			want: Table{
+				QueueTypeArg:               QueueTypeClassic,
+				QueueMaxLenArg:             int64(100),
+				QueueOverflowArg:           QueueOverflowRejectPublishDLX,
+				QueueMessageTTLArg:         int64(60000),
+				QueueTTLArg:                int64(1800000),
+				QueueDeadLetterExchangeArg: "dlx",
+				QueueMaxPriorityArg:        int64(10),
+				QueueVersionArg:            int64(2),
+				"x-custom":                 "v",
+			},
+		},
+		{
+			name: "quorum",
+			args: QuorumQueueArgs{
+				Overflow:             QueueOverflowRejectPublish,
+				DeadLetter:           &DeadLetter{Exchange: "", RoutingKey: "failed"},
+				DeadLetterStrategy:   QueueDeadLetterAtLeastOnce,
+				DeliveryLimit:        5,
+				SingleActiveConsumer: true,
+			},
+			want: Table{
+				QueueTypeArg:                 QueueTypeQuorum,
+				QueueOverflowArg:             QueueOverflowRejectPublish,
+				QueueDeadLetterExchangeArg:   "",
+				QueueDeadLetterRoutingKeyArg: "failed",
+				QueueDeadLetterStrategyArg:   QueueDeadLetterAtLeastOnce,
+				QueueDeliveryLimitArg:        int64(5),
+				SingleActiveConsumerArg:      true,
+			},
+		},
+		{
+			name: "stream",
+			args: StreamQueueArgs{
+				MaxLengthBytes:      1 << 30,
+				MaxAge:              7 * 24 * time.Hour,
+				MaxSegmentSizeBytes: 1 << 20,
+			},
+			want: Table{
+				QueueTypeArg:                 QueueTypeStream,
+				StreamMaxLenBytesArg:         int64(1 << 30),
+				StreamMaxAgeArg:              "604800s",
+				StreamMaxSegmentSizeBytesArg: int64(1 << 20),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.args.Table()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected arguments:\n got: %#v\nwant: %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueArgsRejectInvalidCombinations(t *testing.T) {
+	tests := []struct {
+		name string
+		args QueueArgs
+	}{
+		{"priority on quorum", QuorumQueueArgs{Extra: Table{QueueMaxPriorityArg: 10}}},
+		{"priority on stream", StreamQueueArgs{Extra: Table{QueueMaxPriorityArg: 10}}},
+		{"message ttl on stream", StreamQueueArgs{Extra: Table{QueueMessageTTLArg: 1000}}},
+		{"delivery limit on classic", ClassicQueueArgs{Extra: Table{QueueDeliveryLimitArg: 3}}},
+		{"argument set twice", ClassicQueueArgs{MaxLength: 1, Extra: Table{QueueMaxLenArg: 2}}},
+		{"queue type in extra", QuorumQueueArgs{Extra: Table{QueueTypeArg: QueueTypeClassic}}},
+		{"negative max length", ClassicQueueArgs{MaxLength: -1}},
+		{"unknown overflow", ClassicQueueArgs{Overflow: "drop-tail"}},
+		{"reject-publish-dlx on quorum", QuorumQueueArgs{Overflow: QueueOverflowRejectPublishDLX}},
+		{"invalid version", ClassicQueueArgs{Version: 3}},
+		{"at-least-once without dead letter", QuorumQueueArgs{Overflow: QueueOverflowRejectPublish, DeadLetterStrategy: QueueDeadLetterAtLeastOnce}},
+		{"at-least-once with drop-head", QuorumQueueArgs{DeadLetter: &DeadLetter{}, DeadLetterStrategy: QueueDeadLetterAtLeastOnce}},
+		{"fractional max age", StreamQueueArgs{MaxAge: 1500 * time.Millisecond}},
+		{"message ttl under 1ms", ClassicQueueArgs{MessageTTL: time.Microsecond}},
+		{"quorum message ttl under 1ms", QuorumQueueArgs{MessageTTL: 500 * time.Microsecond}},
+		{"expires under 1ms", ClassicQueueArgs{Expires: time.Microsecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.args.Table(); !errors.Is(err, ErrInvalidArguments) {
+				t.Errorf("expected ErrInvalidArguments, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestExchangeArgsTable(t *testing.T) {
+	got, err := ExchangeArgs{Kind: ExchangeDelayedMessage, DelayedType: ExchangeTopic, AlternateExchange: "unrouted"}.Table()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Table{ExchangeDelayedTypeArg: ExchangeTopic, AlternateExchangeArg: "unrouted"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected arguments:\n got: %#v\nwant: %#v", got, want)
+	}
+
+	for _, args := range []ExchangeArgs{
+		{},
+		{Kind: ExchangeDelayedMessage},
+		{Kind: ExchangeDirect, DelayedType: ExchangeDirect},
+		{Kind: ExchangeFanout, AlternateExchange: "ae", Extra: Table{AlternateExchangeArg: "other"}},
+	} {
+		if _, err := args.Table(); !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("expected ErrInvalidArguments for %+v, got: %v", args, err)
+		}
+	}
+}
+
+func TestQueueDeclareWithArguments(t *testing.T) {
+	declared := make(chan *queueDeclare, 1)
+
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		q := &queueDeclare{}
+		srv.recv(1, q)
+		srv.send(1, &queueDeclareOk{Queue: q.Queue})
+		declared <- q
+	}()
+
+	// Record the topology for recovery.
+	config := defaultConfig()
+	config.Recovery = &Recovery{
+		ReconnectionConfig: &ReconnectionConfig{MaxRetryCount: 1},
+		ConnectionRecovery: manualRecovery{},
+		TopologyRecovery:   &DefaultTopologyRecovery{},
+	}
+
+	c, err := Open(rwc, config)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	if _, err := ch.QueueDeclareWithArguments("jobs", false, false, false, false, QuorumQueueArgs{}); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("expected a transient quorum queue to be rejected, got: %v", err)
+	}
+	if _, err := ch.QueueDeclareWithArguments("jobs", true, false, false, false, QuorumQueueArgs{Extra: Table{QueueMaxPriorityArg: 5}}); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("expected priorities on a quorum queue to be rejected, got: %v", err)
+	}
+
+	if _, err := ch.QueueDeclareWithArguments("jobs", true, false, false, false, QuorumQueueArgs{DeliveryLimit: 3}); err != nil {
+		t.Fatalf("unexpected declare error: %v", err)
+	}
+
+	q := <-declared
+	if q.Queue != "jobs" || !q.Durable || q.Arguments[QueueTypeArg] != QueueTypeQuorum || q.Arguments[QueueDeliveryLimitArg] != int64(3) {
+		t.Errorf("unexpected declaration: %+v", q)
+	}
+
+	topology := ch.TopologyConfiguration(false)
+	if _, ok := topology.Queues["jobs"]; !ok {
+		t.Errorf("expected the queue to be recorded for recovery, got: %+v", topology.Queues)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"time"
+)
+
+// Default batch consumer settings, see NewBatchConsumer.
+const (
+	DefaultBatchSize    = 100
+	DefaultBatchTimeout = time.Second
+)
+
+// BatchHandler processes a batch of deliveries received by a batch Consumer.
+// Returning nil acknowledges the whole batch, returning an error hands it to
+// the consumer's ErrorPolicy.  ctx is cancelled when a Shutdown of the
+// consumer gives up waiting.
+type BatchHandler func(ctx context.Context, batch []Delivery) error
+
+/*
+NewBatchConsumer returns a Consumer that handles deliveries from queue on ch in
+batches.  A batch is handed to handler once it holds BatchSize deliveries, or
+BatchTimeout after its first delivery arrived, whichever comes first.  The
+deliveries still waiting when the consumer is shut down are handed over as a
+last, smaller batch.
+
+The channel's prefetch count is set to BatchSize so that full batches can be
+received, and batches are handled one at a time.  A batch is settled with a
+single acknowledgement of its last delivery with multiple set: when handler
+returns nil, the batch is acknowledged.  When it fails or panics, OnError is
+called with the last delivery of the batch, and by default the whole batch is
+requeued.  With ErrorActionReject, the batch is negatively acknowledged without
+requeueing.
+
+As acknowledging multiple deliveries covers every earlier delivery on the
+channel, the consumer must have the channel to itself.
+*/
+func NewBatchConsumer(ch *Channel, queue string, handler BatchHandler) *Consumer {
+	c := NewConsumer(ch, queue, nil)
+	c.batchHandler = handler
+	c.BatchSize = DefaultBatchSize
+	c.BatchTimeout = DefaultBatchTimeout
+	return c
+}
+
+// requeueAlways is the default ErrorPolicy of batch consumers.
+func requeueAlways(Delivery, error) ErrorAction {
+	return ErrorActionRequeue
+}
+
+// collect gathers deliveries into batches until work is closed.
+func (c *Consumer) collect(work <-chan Delivery) {
+	var (
+		batch   []Delivery
+		timer   *time.Timer
+		expired <-chan time.Time
+	)
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, expired = nil, nil
+		}
+		c.handleBatch(batch)
+		batch = nil
+	}
+
+	for {
+		select {
+		case d, ok := <-work:
+			if !ok {
+				if len(batch) > 0 {
+					flush()
+				}
+				return
+			}
+
+			batch = append(batch, d)
+			if len(batch) == 1 {
+				timer = time.NewTimer(c.BatchTimeout)
+				expired = timer.C
+			}
+			if len(batch) >= c.BatchSize {
+				flush()
+			}
+
+		case <-expired:
+			flush()
+		}
+	}
+}
+
+// handleBatch processes a batch and settles it with its last delivery.
+func (c *Consumer) handleBatch(batch []Delivery) {
+	last := batch[len(batch)-1]
+
+	err := c.invokeBatch(batch)
+	if err == nil {
+		if aerr := last.Ack(true); aerr != nil {
+			Logger.Printf("error acknowledging batch of %d deliveries up to %d: %+v", len(batch), last.DeliveryTag, aerr)
+		}
+		return
+	}
+
+	var aerr error
+	switch c.OnError(last, err) {
+	case ErrorActionAck:
+		aerr = last.Ack(true)
+	case ErrorActionReject:
+		aerr = last.Nack(true, false)
+	default:
+		aerr = last.Nack(true, true)
+	}
+	if aerr != nil {
+		Logger.Printf("error settling failed batch of %d deliveries up to %d: %+v", len(batch), last.DeliveryTag, aerr)
+	}
+}
+
+// invokeBatch calls the batch handler, turning a panic into an error.
+func (c *Consumer) invokeBatch(batch []Delivery) (err error) {
+	defer recoverHandler(&err)
+	return c.batchHandler(c.ctx, batch)
+}
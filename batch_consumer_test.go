@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchConsumerAcksFullBatch(t *testing.T) {
+	const tag = "batch"
+
+	qos := make(chan *basicQos, 1)
+	acked := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		q := &basicQos{}
+		srv.recv(1, q)
+		qos <- q
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		for i := 1; i <= 3; i++ {
+			srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: uint64(i), Body: []byte{byte(i)}})
+		}
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acked <- ack
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	batches := make(chan []Delivery, 1)
+	c := NewBatchConsumer(ch, "warehouse", func(ctx context.Context, batch []Delivery) error {
+		batches <- batch
+		return nil
+	})
+	c.Consumer = tag
+	c.BatchSize = 3
+	c.BatchTimeout = time.Hour
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	if q := <-qos; q.PrefetchCount != 3 {
+		t.Errorf("expected prefetch to match the batch size, got %d", q.PrefetchCount)
+	}
+	if batch := <-batches; len(batch) != 3 || batch[0].Body[0] != 1 || batch[2].Body[0] != 3 {
+		t.Errorf("expected a batch of the 3 deliveries in order, got %d deliveries", len(batch))
+	}
+	if ack := <-acked; ack.DeliveryTag != 3 || !ack.Multiple {
+		t.Errorf("expected a single multiple ack of the last delivery, got: %+v", ack)
+	}
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestBatchConsumerRequeuesFailedBatchAfterTimeout(t *testing.T) {
+	const tag = "batch"
+
+	nacked := make(chan *basicNack, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2})
+
+		nack := &basicNack{}
+		srv.recv(1, nack)
+		nacked <- nack
+	})
+
+	sizes := make(chan int, 1)
+	c := NewBatchConsumer(ch, "warehouse", func(ctx context.Context, batch []Delivery) error {
+		sizes <- len(batch)
+		return errors.New("warehouse unavailable")
+	})
+	c.Consumer = tag
+	c.BatchSize = 10
+	c.BatchTimeout = 20 * time.Millisecond
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	if size := <-sizes; size != 2 {
+		t.Errorf("expected the partial batch to be handed over on timeout, got %d deliveries", size)
+	}
+	if nack := <-nacked; nack.DeliveryTag != 2 || !nack.Multiple || !nack.Requeue {
+		t.Errorf("expected the batch to be requeued with a single multiple nack, got: %+v", nack)
+	}
+}
+
+func TestBatchConsumerShutdownFlushesPartialBatch(t *testing.T) {
+	const tag = "batch"
+
+	delivered := make(chan struct{})
+	acked := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+		close(delivered)
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acked <- ack
+	})
+
+	c := NewBatchConsumer(ch, "warehouse", func(ctx context.Context, batch []Delivery) error { return nil })
+	c.Consumer = tag
+	c.BatchTimeout = time.Hour
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+	<-delivered
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if ack := <-acked; ack.DeliveryTag != 1 || !ack.Multiple {
+		t.Errorf("expected the partial batch to be acknowledged on shutdown, got: %+v", ack)
+	}
+}
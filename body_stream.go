@@ -0,0 +1,259 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"io"
+	"sync"
+)
+
+// bodyStreamBufferSize is the least number of bytes of a streamed body that are
+// buffered before the connection's reader waits for the body to be read.
+const bodyStreamBufferSize = 1024 * 1024
+
+/*
+SetBodyStreamThreshold enables streaming of large delivery bodies for consumers
+on this channel.  Deliveries from Channel.Consume whose body is larger than size
+bytes are sent to the consumer as soon as their content header arrives, with
+Delivery.Body left nil and Delivery.BodyReader yielding the body as its frames
+are received.  A size of zero or less disables streaming, which is the default.
+Deliveries from Channel.Get are always fully buffered.
+
+Body frames are buffered for the BodyReader as they arrive, up to the larger of
+size and 1 MiB per streamed delivery, so a consumer reading slightly slower than the server
+sends does not hold up the connection.
+
+Important: once that buffer is full, the connection's reader is paused until
+the consumer reads more of the body, so no further frames are received for
+any channel on the connection, including heartbeats and the replies to RPCs,
+until the body is read to EOF or the BodyReader is closed.  The backpressure is
+connection-wide: AMQP has no flow control for a single delivery.  Applications
+must read streamed bodies promptly and should use a dedicated connection for
+consumers of large messages.  Closing the BodyReader early discards the rest
+of the body.
+
+If the channel or connection is closed while a body is being streamed, reads
+from the BodyReader return the closing error.
+*/
+func (ch *Channel) SetBodyStreamThreshold(size int) {
+	ch.bodyStreamThreshold.Store(int64(size))
+}
+
+// shouldStreamBody reports whether the content with the given header should be
+// streamed to the consumer instead of being buffered by recvContent.
+func (ch *Channel) shouldStreamBody(header *headerFrame) bool {
+	threshold := ch.bodyStreamThreshold.Load()
+	if threshold <= 0 || header.Size <= uint64(threshold) {
+		return false
+	}
+	_, ok := ch.message.(*basicDeliver)
+	return ok
+}
+
+// startBodyStream dispatches the delivery being received with a BodyReader
+// that is fed by recvBodyStream.
+func (ch *Channel) startBodyStream() {
+	limit := int(ch.bodyStreamThreshold.Load())
+	if limit < bodyStreamBufferSize {
+		limit = bodyStreamBufferSize
+	}
+	p := newBodyPipe(limit)
+
+	ch.bodyStreamM.Lock()
+	ch.bodyStream = p
+	ch.bodyStreamRemaining = ch.header.Size
+	if ch.IsClosed() || ch.lifeCycle.State() == StateClosing {
+		// Close or shutdown already aborted any stream, so this one would
+		// never be aborted and could block the reader forever.
+		p.CloseWithError(ErrClosed)
+	}
+	ch.bodyStreamM.Unlock()
+
+	ch.message.setContent(ch.header.Properties, nil)
+	msg := ch.message.(*basicDeliver)
+	delivery := newDelivery(ch, msg)
+	delivery.BodyReader = p
+
+	if !ch.dispatchDelivery(msg.ConsumerTag, delivery) {
+		// Nobody will read the body, discard it.
+		p.CloseWithError(ErrClosed)
+	}
+}
+
+// state after the header of a streamed delivery, writing body frames to the
+// delivery's BodyReader until the length defined by the header has been
+// reached
+func (ch *Channel) recvBodyStream(f frame) {
+	switch frame := f.(type) {
+	case *methodFrame:
+		// interrupt content and handle method
+		ch.endBodyStream(io.ErrUnexpectedEOF)
+		ch.recvMethod(f)
+
+	case *headerFrame:
+		// drop and reset
+		ch.endBodyStream(io.ErrUnexpectedEOF)
+		ch.transition((*Channel).recvMethod)
+
+	case *bodyFrame:
+		ch.bodyStreamM.Lock()
+		w := ch.bodyStream
+		ch.bodyStreamM.Unlock()
+
+		// Blocks while the buffer is full until more of the body is read,
+		// or fails straight away once the reader has been closed, in which
+		// case the frame is discarded.
+		_, _ = w.Write(frame.Body)
+
+		if n := uint64(len(frame.Body)); n < ch.bodyStreamRemaining {
+			ch.bodyStreamRemaining -= n
+			ch.transition((*Channel).recvBodyStream)
+			return
+		}
+
+		ch.endBodyStream(nil)
+		ch.transition((*Channel).recvMethod)
+
+	default:
+		panic("unexpected frame type")
+	}
+}
+
+// endBodyStream completes the streamed body, with err returned to the reader
+// after the data written so far, or io.EOF when err is nil.
+func (ch *Channel) endBodyStream(err error) {
+	ch.bodyStreamM.Lock()
+	defer ch.bodyStreamM.Unlock()
+
+	if ch.bodyStream != nil {
+		_ = ch.bodyStream.CloseWithError(err)
+		ch.bodyStream = nil
+	}
+	ch.bodyStreamRemaining = 0
+}
+
+// abortBodyStream fails the body currently being streamed, if any, unblocking
+// the connection's reader if it is waiting for the body to be read.
+func (ch *Channel) abortBodyStream(err error) {
+	ch.bodyStreamM.Lock()
+	defer ch.bodyStreamM.Unlock()
+
+	if ch.bodyStream != nil {
+		_ = ch.bodyStream.CloseWithError(err)
+	}
+}
+
+// bodyPipe connects the connection's reader to the BodyReader of a streamed
+// delivery.  Unlike io.Pipe, writes only block while limit bytes are waiting to
+// be read.
+type bodyPipe struct {
+	m      sync.Mutex
+	cond   sync.Cond
+	buf    []byte
+	off    int // read offset in buf
+	limit  int
+	err    error // set once the writer is done, returned after buf is read
+	closed bool  // set once the reader is closed
+}
+
+func newBodyPipe(limit int) *bodyPipe {
+	if limit < 1 {
+		limit = 1
+	}
+	p := &bodyPipe{limit: limit}
+	p.cond.L = &p.m
+	return p
+}
+
+// Write buffers b, waiting for the reader whenever the buffer is full.
+func (p *bodyPipe) Write(b []byte) (int, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	written := 0
+	for written < len(b) {
+		for p.unread() >= p.limit && !p.closed && p.err == nil {
+			p.cond.Wait()
+		}
+		if p.closed {
+			return written, io.ErrClosedPipe
+		}
+		if p.err != nil {
+			return written, p.err
+		}
+
+		n := p.limit - p.unread()
+		if n > len(b)-written {
+			n = len(b) - written
+		}
+		if len(p.buf)+n > cap(p.buf) && p.off >= p.unread() {
+			// Reuse the space already read rather than growing buf, which
+			// costs no more than copying what was read.
+			p.buf = p.buf[:copy(p.buf, p.buf[p.off:])]
+			p.off = 0
+		}
+		p.buf = append(p.buf, b[written:written+n]...)
+		written += n
+		p.cond.Broadcast()
+	}
+	return written, nil
+}
+
+// Read returns the buffered body, then the error the writer was closed with.
+func (p *bodyPipe) Read(b []byte) (int, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for p.unread() == 0 && !p.closed && p.err == nil {
+		p.cond.Wait()
+	}
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if p.unread() == 0 {
+		return 0, p.err
+	}
+
+	n := copy(b, p.buf[p.off:])
+	p.off += n
+	if p.off == len(p.buf) {
+		p.buf, p.off = p.buf[:0], 0
+	}
+	p.cond.Broadcast()
+	return n, nil
+}
+
+// unread returns the number of buffered bytes not read yet.
+func (p *bodyPipe) unread() int {
+	return len(p.buf) - p.off
+}
+
+// CloseWithError completes the body as seen by the reader, with err returned
+// after the buffered data, or io.EOF when err is nil.  Only the first error
+// is kept, as with io.PipeWriter.
+func (p *bodyPipe) CloseWithError(err error) error {
+	if err == nil {
+		err = io.EOF
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.err == nil {
+		p.err = err
+	}
+	p.cond.Broadcast()
+	return nil
+}
+
+// Close discards the rest of the body, failing any further writes.
+func (p *bodyPipe) Close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.closed = true
+	p.buf, p.off = nil, 0
+	p.cond.Broadcast()
+	return nil
+}
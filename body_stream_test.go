@@ -0,0 +1,297 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// sendChunked writes a delivery whose body is split into one body frame per
+// chunk.
+func (t *server) sendChunked(channel int, m *basicDeliver, chunks ...[]byte) {
+	size := 0
+	for _, c := range chunks {
+		size += len(c)
+	}
+
+	class, _ := m.id()
+	frames := []frame{
+		&methodFrame{ChannelId: uint16(channel), Method: m},
+		&headerFrame{ChannelId: uint16(channel), ClassId: class, Size: uint64(size)},
+	}
+	for _, c := range chunks {
+		frames = append(frames, &bodyFrame{ChannelId: uint16(channel), Body: c})
+	}
+
+	for _, f := range frames {
+		if err := t.w.WriteFrame(f); err != nil {
+			t.Errorf("WriteFrame error: %v", err)
+			return
+		}
+	}
+}
+
+func consumeWithBodyStreaming(t *testing.T, srv *server, rwc io.ReadWriteCloser, tag string) (*Channel, <-chan Delivery) {
+	t.Helper()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	ch.SetBodyStreamThreshold(16)
+
+	deliveries, err := ch.Consume("queue", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	return ch, deliveries
+}
+
+func TestBodyStreamDeliversLargeBodiesAsReader(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	chunks := [][]byte{
+		bytes.Repeat([]byte("a"), 10),
+		bytes.Repeat([]byte("b"), 10),
+		bytes.Repeat([]byte("c"), 10),
+	}
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, chunks...)
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2}, []byte("small"))
+	}()
+
+	_, deliveries := consumeWithBodyStreaming(t, srv, rwc, tag)
+
+	large := <-deliveries
+	if large.Body != nil || large.BodyReader == nil {
+		t.Fatalf("expected a streamed body, got Body: %q, BodyReader: %v", large.Body, large.BodyReader)
+	}
+
+	body, err := io.ReadAll(large.BodyReader)
+	if err != nil {
+		t.Fatalf("unexpected error reading streamed body: %v", err)
+	}
+	if want := bytes.Join(chunks, nil); !bytes.Equal(want, body) {
+		t.Errorf("expected streamed body %q, got %q", want, body)
+	}
+
+	small := <-deliveries
+	if small.BodyReader != nil || string(small.Body) != "small" {
+		t.Errorf("expected a buffered body, got Body: %q, BodyReader: %v", small.Body, small.BodyReader)
+	}
+}
+
+func TestBodyStreamCloseReaderDiscardsRemainder(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, bytes.Repeat([]byte("a"), 20), bytes.Repeat([]byte("b"), 20))
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2}, []byte("next"))
+	}()
+
+	_, deliveries := consumeWithBodyStreaming(t, srv, rwc, tag)
+
+	large := <-deliveries
+	if err := large.BodyReader.Close(); err != nil {
+		t.Fatalf("unexpected error closing streamed body: %v", err)
+	}
+
+	next := <-deliveries
+	if want, got := uint64(2), next.DeliveryTag; want != got {
+		t.Fatalf("unexpected delivery tag: want: %d, got: %d", want, got)
+	}
+	if string(next.Body) != "next" {
+		t.Errorf("expected body %q, got %q", "next", next.Body)
+	}
+}
+
+func TestBodyStreamChannelCloseUnblocksUnreadBody(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, bytes.Repeat([]byte("a"), 20), bytes.Repeat([]byte("b"), 20))
+
+		srv.recv(1, &channelClose{})
+		srv.send(1, &channelCloseOk{})
+	}()
+
+	ch, deliveries := consumeWithBodyStreaming(t, srv, rwc, tag)
+
+	large := <-deliveries
+
+	if err := ch.Close(); err != nil {
+		t.Fatalf("unexpected error closing channel: %v", err)
+	}
+
+	if _, err := io.ReadAll(large.BodyReader); err != ErrClosed {
+		t.Errorf("expected reading the aborted body to fail with ErrClosed, got: %v", err)
+	}
+}
+
+func TestBodyStreamBuffersUnreadBody(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, bytes.Repeat([]byte("a"), 20), bytes.Repeat([]byte("b"), 20))
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2}, []byte("next"))
+	}()
+
+	_, deliveries := consumeWithBodyStreaming(t, srv, rwc, tag)
+
+	large := <-deliveries
+
+	// The body fits in the buffer, so the next delivery arrives without the
+	// streamed body being read.
+	select {
+	case next := <-deliveries:
+		if string(next.Body) != "next" {
+			t.Errorf("expected body %q, got %q", "next", next.Body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the next delivery while the streamed body is unread")
+	}
+
+	body, err := io.ReadAll(large.BodyReader)
+	if err != nil {
+		t.Fatalf("unexpected error reading streamed body: %v", err)
+	}
+	if len(body) != 40 {
+		t.Errorf("expected 40 bytes of streamed body, got %d", len(body))
+	}
+}
+
+func TestBodyPipeWaitsWhileFull(t *testing.T) {
+	p := newBodyPipe(4)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := p.Write([]byte("abcdefgh"))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		t.Fatalf("expected the write to wait for the full buffer to be read, got: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	buf := make([]byte, 8)
+	n, err := p.Read(buf)
+	if err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected the buffered bytes, got: %q, %v", buf[:n], err)
+	}
+
+	if err := <-written; err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	p.CloseWithError(nil)
+
+	rest, err := io.ReadAll(p)
+	if err != nil || string(rest) != "efgh" {
+		t.Errorf("expected the rest of the body, got: %q, %v", rest, err)
+	}
+
+	p.Close()
+	if _, err := p.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("expected writes to fail once the reader is closed, got: %v", err)
+	}
+}
+
+func TestBodyPipeSmallReads(t *testing.T) {
+	const limit = 1 << 10
+	p := newBodyPipe(limit)
+
+	body := make([]byte, 1<<20)
+	for i := range body {
+		body[i] = byte(i % 251)
+	}
+	go func() {
+		for off := 0; off < len(body); off += 100 {
+			end := off + 100
+			if end > len(body) {
+				end = len(body)
+			}
+			if _, err := p.Write(body[off:end]); err != nil {
+				t.Errorf("unexpected write error: %v", err)
+				return
+			}
+		}
+		p.CloseWithError(nil)
+	}()
+
+	var got bytes.Buffer
+	buf := make([]byte, 7)
+	maxCap := 0
+	for {
+		n, err := p.Read(buf)
+		got.Write(buf[:n])
+
+		p.m.Lock()
+		if c := cap(p.buf); c > maxCap {
+			maxCap = c
+		}
+		p.m.Unlock()
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected read error: %v", err)
+		}
+	}
+
+	if !bytes.Equal(got.Bytes(), body) {
+		t.Errorf("expected the body to be read whole and in order, got %d bytes", got.Len())
+	}
+	if maxCap > 4*limit {
+		t.Errorf("expected the buffer to reuse the space already read, grew to %d bytes", maxCap)
+	}
+}
@@ -66,6 +66,7 @@ type Channel struct {
 	closeOnce   sync.Once    // Ensures closeResources() runs at most once, however it's reached.
 	m           sync.Mutex   // Mutex for the channel.
 	notifyM     sync.RWMutex // Mutex for the notify state.
+	callM       sync.Mutex   // Serializes RPCs, as replies arrive in order.
 
 	connection *Connection
 
@@ -88,6 +89,19 @@ type Channel struct {
 	// publishing should pause until false is sent to listeners.
 	flows []chan bool
 
+	// flowResumed is non-nil while the server has paused publishing with
+	// channel.flow, and is closed when it resumes it.
+	flowM       sync.Mutex
+	flowResumed chan struct{}
+
+	// acks holds acknowledgements back when coalescing is enabled, see
+	// EnableAckCoalescing.
+	acks atomic.Pointer[ackCoalescer]
+
+	// incarnation counts the recoveries of the channel, after which delivery
+	// tags start over.  Deliveries are stamped with it, see ErrStaleDelivery.
+	incarnation atomic.Uint64
+
 	// Listeners for returned publishings for unroutable messages on mandatory
 	// publishings or undeliverable messages on immediate publishings.
 	returns []chan Return
@@ -96,6 +110,10 @@ type Channel struct {
 	// a consumer has been cancelled.
 	cancels []chan string
 
+	// Listeners for the attempts to subscribe consumers again after the
+	// server cancelled them.
+	resubscribes []chan ResubscribeEvent
+
 	// Allocated when in confirm mode in order to track publish counter and order confirms
 	confirms   *confirms
 	confirming atomic.Bool
@@ -111,6 +129,14 @@ type Channel struct {
 	header  *headerFrame
 	body    []byte
 
+	// Deliveries with a body larger than bodyStreamThreshold are dispatched
+	// as soon as their header arrives and their body is written to
+	// bodyStream as frames arrive, see SetBodyStreamThreshold.
+	bodyStreamThreshold atomic.Int64
+	bodyStreamM         sync.Mutex // guards bodyStream against aborts from Close and shutdown
+	bodyStream          *bodyPipe
+	bodyStreamRemaining uint64 // only mutated from recv
+
 	reconnecting sync.Mutex // Mutex for reconnecting channel.
 	lifeCycle    *lifeCycle // The current state of the channel.
 
@@ -226,7 +252,7 @@ func cloneSlice[T any](s []T) []T {
 
 // Constructs a new channel with the given framing rules
 func newChannel(c *Connection, id uint16) *Channel {
-	return &Channel{
+	ch := &Channel{
 		connection: c,
 		id:         id,
 		rpc:        make(chan message),
@@ -237,6 +263,8 @@ func newChannel(c *Connection, id uint16) *Channel {
 		close:      make(chan struct{}),
 		lifeCycle:  newLifeCycle(),
 	}
+	ch.consumers.throttle = ch.throttle
+	return ch
 }
 
 // Signal that from now on, Channel.send() should call Channel.sendClosed()
@@ -305,6 +333,20 @@ func (ch *Channel) shutdown(e *Error) {
 		}
 	}
 
+	if e != nil {
+		ch.abortBodyStream(e)
+	} else {
+		ch.abortBodyStream(ErrClosed)
+	}
+
+	// Publishers waiting for the flow to resume fail on the closed channel.
+	ch.setFlow(true)
+
+	// Acknowledgements held back can no longer be sent.
+	if a := ch.acks.Load(); a != nil {
+		a.reset()
+	}
+
 	close(ch.errors)
 	close(ch.close)
 
@@ -330,6 +372,54 @@ func (ch *Channel) send(msg message) (err error) {
 	return ch.sendOpen(msg)
 }
 
+// awaitRecovery calls op.  When Recovery.WaitForRecovery is set, op is only
+// called once the channel and its connection are in StateOpen, and is called
+// again after the next transition to StateOpen if it fails with ErrClosed.
+// The wait ends with ctx's error if ctx is done, or with the terminal error
+// once the channel or its connection reaches StateClosed.
+func (ch *Channel) awaitRecovery(ctx context.Context, op func() error) error {
+	if !ch.waitsForRecovery() {
+		return op()
+	}
+
+	for {
+		state, changed, closeErr := ch.lifeCycle.current()
+		connState, connChanged, connErr := ch.connection.lifeCycle.current()
+
+		switch {
+		case state == StateClosed:
+			return closedError(closeErr)
+		case connState == StateClosed:
+			return closedError(connErr)
+		case state == StateOpen && connState == StateOpen && !ch.IsClosed():
+			if err := op(); err != ErrClosed || !ch.waitsForRecovery() {
+				return err
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-connChanged:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitsForRecovery reports whether operations wait for the channel to be
+// recovered, see Recovery.WaitForRecovery.
+func (ch *Channel) waitsForRecovery() bool {
+	return ch.connection.IsRecoveryEnabled() && ch.connection.Config.Recovery.WaitForRecovery
+}
+
+// closedError returns the error to report for a transition to StateClosed.
+func closedError(err error) error {
+	if err == nil {
+		return ErrClosed
+	}
+	return err
+}
+
 func (ch *Channel) open() error {
 	return ch.call(&channelOpen{}, &channelOpenOk{})
 }
@@ -337,6 +427,14 @@ func (ch *Channel) open() error {
 // Performs a request/response call for when the message is not NoWait and is
 // specified as Synchronous.
 func (ch *Channel) call(req message, res ...message) error {
+	// The server replies to synchronous requests in order, so a request must
+	// wait for the reply to the previous one, which may have been sent by
+	// another goroutine such as a background resubscription.
+	if req.wait() {
+		ch.callM.Lock()
+		defer ch.callM.Unlock()
+	}
+
 	if err := ch.send(req); err != nil {
 		return err
 	}
@@ -491,6 +589,7 @@ func (ch *Channel) dispatch(msg message) {
 		ch.connection.closeChannel(ch, newError(m.ReplyCode, m.ReplyText))
 
 	case *channelFlow:
+		ch.setFlow(m.Active)
 		ch.notifyM.RLock()
 		notifyAll(ch.flows, m.Active)
 		ch.notifyM.RUnlock()
@@ -499,17 +598,26 @@ func (ch *Channel) dispatch(msg message) {
 		}
 
 	case *basicCancel:
-		queueName, _ := ch.consumers.queueForTag(m.ConsumerTag)
+		incarnation := ch.incarnation.Load()
+		config, found := ch.consumers.configFor(m.ConsumerTag)
 		ch.notifyM.RLock()
 		notifyAll(ch.cancels, m.ConsumerTag)
 		ch.notifyM.RUnlock()
-		ch.consumers.cancel(m.ConsumerTag)
-		if queueName != "" {
-			ch.connection.maybeDeleteRecordedAutoDeleteQueue(queueName)
+		if found && config.Resubscribe != nil {
+			// Keep the consumer's deliveries open while subscribing again.
+			go ch.resubscribe(m.ConsumerTag, *config.Resubscribe, incarnation)
+		} else {
+			ch.consumers.cancel(m.ConsumerTag)
+			if found && config.Queue != "" {
+				ch.connection.maybeDeleteRecordedAutoDeleteQueue(config.Queue)
+			}
 		}
 
 	case *basicReturn:
 		ret := newReturn(*m)
+		if ch.confirming.Load() {
+			ch.confirms.returned(*ret)
+		}
 		ch.notifyM.RLock()
 		notifyAll(ch.returns, *ret)
 		ch.notifyM.RUnlock()
@@ -533,7 +641,7 @@ func (ch *Channel) dispatch(msg message) {
 		}
 
 	case *basicDeliver:
-		ch.consumers.send(m.ConsumerTag, newDelivery(ch, m))
+		ch.dispatchDelivery(m.ConsumerTag, ch.decodeDelivery(newDelivery(ch, m)))
 		// TODO log failed consumer and close channel, this can happen when
 		// deliveries are in flight and a no-wait cancel has happened
 
@@ -597,6 +705,17 @@ func (ch *Channel) recvHeader(f frame) {
 			ch.transition((*Channel).recvMethod)
 			return
 		}
+
+		if ch.shouldStreamBody(frame) {
+			ch.startBodyStream()
+			ch.transition((*Channel).recvBodyStream)
+			return
+		}
+
+		if max := ch.connection.Config.MaxMessageSize; max > 0 && frame.Size > uint64(max) {
+			ch.rejectOversizedMessage()
+			return
+		}
 		ch.transition((*Channel).recvContent)
 
 	case *bodyFrame:
@@ -644,6 +763,84 @@ func (ch *Channel) recvContent(f frame) {
 	}
 }
 
+// decodeDelivery decompresses the delivery's body according to
+// Config.Compression, leaving it unchanged if it cannot be decompressed.
+func (ch *Channel) decodeDelivery(d *Delivery) *Delivery {
+	if err := ch.connection.Config.Compression.decode(d, ch.connection.Config.MaxMessageSize); err != nil {
+		Logger.Printf("delivering body without decompressing it, content encoding: %q, channel id: %d error: %+v", d.ContentEncoding, ch.id, err)
+	}
+	return d
+}
+
+// rejectOversizedMessage handles a message whose header announces a body
+// larger than Config.MaxMessageSize.  Deliveries and returns are dropped when
+// Config.DropOversizedMessages is set, otherwise the channel is closed.
+func (ch *Channel) rejectOversizedMessage() {
+	if ch.connection.Config.DropOversizedMessages {
+		switch m := ch.message.(type) {
+		case *basicDeliver:
+			Logger.Printf("dropping delivery %d of %d bytes exceeding the maximum message size, channel id: %d", m.DeliveryTag, ch.header.Size, ch.id)
+			if !ch.consumers.isAutoAck(m.ConsumerTag) {
+				if err := ch.Nack(m.DeliveryTag, false, false); err != nil {
+					Logger.Printf("error sending basicNack, channel id: %d error: %+v", ch.id, err)
+				}
+			}
+			// the body frames are dropped by recvMethod
+			ch.transition((*Channel).recvMethod)
+			return
+
+		case *basicReturn:
+			Logger.Printf("dropping return of %d bytes exceeding the maximum message size, channel id: %d", ch.header.Size, ch.id)
+			ch.transition((*Channel).recvMethod)
+			return
+		}
+	}
+
+	// Frames already in flight, such as the body of this message, keep
+	// arriving until the server replies with channel.close-ok, so the channel
+	// stays registered and its id reserved until then, see recvClosing.
+	ch.m.Lock()
+	ch.setClosed()
+	if err := ch.connection.send(&methodFrame{
+		ChannelId: ch.id,
+		Method: &channelClose{
+			ReplyCode: uint16(ErrMessageTooLarge.Code),
+			ReplyText: ErrMessageTooLarge.Reason,
+		},
+	}); err != nil {
+		Logger.Printf("error sending channelClose, channel id: %d error: %+v", ch.id, err)
+	}
+	ch.m.Unlock()
+
+	ch.transition((*Channel).recvClosing)
+}
+
+// state after the client closed the channel on its own, discarding every frame
+// until the server replies with channel.close-ok, when the channel is shut
+// down and released
+func (ch *Channel) recvClosing(f frame) {
+	if frame, ok := f.(*methodFrame); ok {
+		switch frame.Method.(type) {
+		case *channelClose:
+			// Both peers closed the channel at the same time, see
+			// dispatchClosed.
+			if err := ch.send(&channelCloseOk{}); err != nil {
+				Logger.Printf("error sending channelCloseOk, channel id: %d error: %+v", ch.id, err)
+			}
+			ch.transition((*Channel).recvMethod)
+			ch.connection.closeChannel(ch, ErrMessageTooLarge)
+			return
+
+		case *channelCloseOk:
+			ch.transition((*Channel).recvMethod)
+			ch.connection.closeChannel(ch, ErrMessageTooLarge)
+			return
+		}
+	}
+
+	ch.transition((*Channel).recvClosing)
+}
+
 /*
 Close initiate a clean channel closure by sending a close message with the error
 code set to '200'.
@@ -675,6 +872,16 @@ func (ch *Channel) Close() error {
 
 	ch.lifeCycle.SetState(StateClosing, nil)
 
+	// The connection's reader may be blocked writing a streamed body nobody
+	// reads anymore, which would keep channel.close-ok from being received.
+	ch.abortBodyStream(ErrClosed)
+
+	if a := ch.acks.Load(); a != nil {
+		if err := a.flushAll(); err != nil {
+			Logger.Printf("error sending coalesced acknowledgements, channel id: %d error: %+v", ch.id, err)
+		}
+	}
+
 	defer ch.connection.closeChannel(ch, nil)
 	return ch.call(
 		&channelClose{ReplyCode: replySuccess},
@@ -756,7 +963,8 @@ func (ch *Channel) closeRecovery() {
 /*
 NotifyFlow registers a listener for basic.flow methods sent by the server.
 When `false` is sent on one of the listener channels, all publishers should
-pause until a `true` is sent.
+pause until a `true` is sent.  The publishing methods of this channel do so on
+their own, see Channel.FlowActive.
 
 The server may ask the producer to pause or restart the flow of Publishings
 sent by on a channel. This is a simple flow-control mechanism that a server can
@@ -798,6 +1006,78 @@ func (ch *Channel) NotifyFlow(c chan bool) chan bool {
 	return c
 }
 
+/*
+FlowActive returns false while the server has paused publishing on this channel
+with channel.flow.
+
+While the flow is paused, publishing methods wait for it to resume before
+sending anything: the variants taking a context until the context is done, and
+the others for at most Config.FlowControlTimeout.  Acknowledgements and other
+methods are not held back.
+*/
+func (ch *Channel) FlowActive() bool {
+	ch.flowM.Lock()
+	defer ch.flowM.Unlock()
+	return ch.flowResumed == nil
+}
+
+func (ch *Channel) setFlow(active bool) {
+	ch.flowM.Lock()
+	defer ch.flowM.Unlock()
+
+	switch {
+	case active && ch.flowResumed != nil:
+		close(ch.flowResumed)
+		ch.flowResumed = nil
+	case !active && ch.flowResumed == nil:
+		ch.flowResumed = make(chan struct{})
+	}
+}
+
+// defaultFlowControlTimeout is used when Config.FlowControlTimeout is 0.
+const defaultFlowControlTimeout = 30 * time.Second
+
+// flowControlTimeout returns the timeout of awaitFlow for publishing methods
+// without a context, see Config.FlowControlTimeout.
+func (ch *Channel) flowControlTimeout() time.Duration {
+	switch timeout := ch.connection.Config.FlowControlTimeout; {
+	case timeout == 0:
+		return defaultFlowControlTimeout
+	case timeout < 0:
+		return 0
+	default:
+		return timeout
+	}
+}
+
+// awaitFlow waits for a paused flow to resume.  A positive timeout fails the
+// wait with ErrFlowTimeout.
+func (ch *Channel) awaitFlow(ctx context.Context, timeout time.Duration) error {
+	ch.flowM.Lock()
+	resumed := ch.flowResumed
+	ch.flowM.Unlock()
+
+	if resumed == nil {
+		return nil
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+
+	select {
+	case <-resumed:
+		return nil
+	case <-expired:
+		return ErrFlowTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 /*
 NotifyReturn registers a listener for basic.return methods.  These can be sent
 from the server when a publish is undeliverable either from the mandatory or
@@ -1508,26 +1788,40 @@ be dropped. RabbitMQ will requeue messages not acknowledged. In other words, dro
 messages in this way won't be lost.
 */
 func (ch *Channel) ConsumeWithContext(ctx context.Context, queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args Table) (<-chan Delivery, error) {
+	return ch.consume(ctx, consumerConfig{
+		Queue:     queue,
+		Consumer:  consumer,
+		AutoAck:   autoAck,
+		Exclusive: exclusive,
+		NoLocal:   noLocal,
+		NoWait:    noWait,
+		Args:      args,
+	})
+}
+
+// consume subscribes the consumer described by config.
+func (ch *Channel) consume(ctx context.Context, config consumerConfig) (<-chan Delivery, error) {
 	// When we return from ch.call, there may be a delivery already for the
 	// consumer that hasn't been added to the consumer hash yet.  Because of
 	// this, we never rely on the server picking a consumer tag for us.
 
-	if err := args.Validate(); err != nil {
+	if err := config.Args.Validate(); err != nil {
 		return nil, err
 	}
 
-	if consumer == "" {
-		consumer = uniqueConsumerTag()
+	if config.Consumer == "" {
+		config.Consumer = uniqueConsumerTag()
 	}
+	consumer := config.Consumer
 
 	req := &basicConsume{
-		Queue:       queue,
+		Queue:       config.Queue,
 		ConsumerTag: consumer,
-		NoLocal:     noLocal,
-		NoAck:       autoAck,
-		Exclusive:   exclusive,
-		NoWait:      noWait,
-		Arguments:   args,
+		NoLocal:     config.NoLocal,
+		NoAck:       config.AutoAck,
+		Exclusive:   config.Exclusive,
+		NoWait:      config.NoWait,
+		Arguments:   config.Args,
 	}
 	res := &basicConsumeOk{}
 
@@ -1537,21 +1831,19 @@ func (ch *Channel) ConsumeWithContext(ctx context.Context, queue, consumer strin
 		return nil, ctx.Err()
 	}
 
-	deliveries := make(chan Delivery)
+	var deliveries chan Delivery
+	err := ch.awaitRecovery(ctx, func() error {
+		deliveries = make(chan Delivery)
 
-	config := consumerConfig{
-		Queue:     queue,
-		Consumer:  consumer,
-		AutoAck:   autoAck,
-		Exclusive: exclusive,
-		NoLocal:   noLocal,
-		NoWait:    noWait,
-		Args:      args,
-	}
-	ch.consumers.add(consumer, deliveries, config)
+		ch.consumers.add(consumer, deliveries, config)
 
-	if err := ch.call(req, res); err != nil {
-		ch.consumers.cancel(consumer)
+		if err := ch.call(req, res); err != nil {
+			ch.consumers.cancel(consumer)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -1850,9 +2142,10 @@ func (ch *Channel) Publish(exchange, key string, mandatory, immediate bool, msg
 PublishWithContext sends a Publishing from the client to an exchange on the server.
 
 If the context is already cancelled when PublishWithContext is called, it
-returns the context error immediately without attempting to publish.  Context
-cancellation after the call has started does not interrupt an in-flight
-Publish, as the underlying I/O is not context-aware.
+returns the context error immediately without attempting to publish.  The
+context bounds the wait while the server has paused the channel's flow, see
+Channel.FlowActive.  Context cancellation once the publishing is being sent does
+not interrupt it, as the underlying I/O is not context-aware.
 
 When you want a single message to be delivered to a single queue, you can
 publish to the default exchange with the routingKey of the queue name.  This is
@@ -1888,7 +2181,10 @@ func (ch *Channel) PublishWithContext(ctx context.Context, exchange, key string,
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		return ch.Publish(exchange, key, mandatory, immediate, msg)
+		return ch.awaitRecovery(ctx, func() error {
+			_, err := ch.publish(ctx, 0, exchange, key, mandatory, immediate, msg)
+			return err
+		})
 	}
 }
 
@@ -1897,18 +2193,51 @@ PublishWithDeferredConfirm behaves identically to Publish, but additionally
 returns a DeferredConfirmation, allowing the caller to wait on the publisher
 confirmation for this message. If the channel has not been put into confirm
 mode, the DeferredConfirmation will be nil.
+
+When mandatory is true and the server returns the publishing as unroutable,
+the basic.return is correlated with the confirmation that follows it and is
+available from DeferredConfirmation.Returned once the confirmation arrives.
+Returns are matched by exchange, routing key, MessageId and CorrelationId, so
+set a unique MessageId when several identical publishings may be in flight.
+Listeners registered with NotifyReturn are still notified.
 */
 func (ch *Channel) PublishWithDeferredConfirm(exchange, key string, mandatory, immediate bool, msg Publishing) (*DeferredConfirmation, error) {
+	return ch.publish(context.Background(), ch.flowControlTimeout(), exchange, key, mandatory, immediate, msg)
+}
+
+// publish sends a publishing once the channel's flow is active, waiting for
+// ctx or flowTimeout when it is paused.
+func (ch *Channel) publish(ctx context.Context, flowTimeout time.Duration, exchange, key string, mandatory, immediate bool, msg Publishing) (*DeferredConfirmation, error) {
 	if err := msg.Headers.Validate(); err != nil {
 		return nil, err
 	}
 
+	if err := ch.connection.Config.Compression.encode(&msg); err != nil {
+		return nil, err
+	}
+
+	if max := ch.connection.Config.MaxMessageSize; max > 0 && len(msg.Body) > max {
+		return nil, ErrMessageTooLarge
+	}
+
+	if err := ch.awaitFlow(ctx, flowTimeout); err != nil {
+		return nil, err
+	}
+
 	ch.m.Lock()
 	defer ch.m.Unlock()
 
 	var dc *DeferredConfirmation
 	if ch.confirming.Load() {
 		dc = ch.confirms.publish()
+		if mandatory {
+			ch.confirms.expectReturn(dc.DeliveryTag, returnRoute{
+				exchange:      exchange,
+				routingKey:    key,
+				messageId:     msg.MessageId,
+				correlationId: msg.CorrelationId,
+			})
+		}
 	}
 
 	if err := ch.send(&basicPublish{
@@ -1917,21 +2246,7 @@ func (ch *Channel) PublishWithDeferredConfirm(exchange, key string, mandatory, i
 		Mandatory:  mandatory,
 		Immediate:  immediate,
 		Body:       msg.Body,
-		Properties: properties{
-			Headers:         msg.Headers,
-			ContentType:     msg.ContentType,
-			ContentEncoding: msg.ContentEncoding,
-			DeliveryMode:    msg.DeliveryMode,
-			Priority:        msg.Priority,
-			CorrelationId:   msg.CorrelationId,
-			ReplyTo:         msg.ReplyTo,
-			Expiration:      msg.Expiration,
-			MessageId:       msg.MessageId,
-			Timestamp:       msg.Timestamp,
-			Type:            msg.Type,
-			UserId:          msg.UserId,
-			AppId:           msg.AppId,
-		},
+		Properties: msg.properties(),
 	}); err != nil {
 		if ch.confirming.Load() {
 			ch.confirms.unpublish()
@@ -1949,16 +2264,22 @@ for this message. If the channel has not been put into confirm mode,
 the DeferredConfirmation will be nil.
 
 If the context is already cancelled when PublishWithDeferredConfirmWithContext is called, it
-returns the context error immediately without attempting to publish.  Context
-cancellation after the call has started does not interrupt an in-flight
-PublishWithDeferredConfirm, as the underlying I/O is not context-aware.
+returns the context error immediately without attempting to publish.  The
+context bounds the wait while the server has paused the channel's flow, see
+Channel.FlowActive.  Context cancellation once the publishing is being sent does
+not interrupt it, as the underlying I/O is not context-aware.
 */
 func (ch *Channel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg Publishing) (*DeferredConfirmation, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
-		return ch.PublishWithDeferredConfirm(exchange, key, mandatory, immediate, msg)
+		var dc *DeferredConfirmation
+		err := ch.awaitRecovery(ctx, func() (err error) {
+			dc, err = ch.publish(ctx, 0, exchange, key, mandatory, immediate, msg)
+			return err
+		})
+		return dc, err
 	}
 }
 
@@ -1989,7 +2310,10 @@ func (ch *Channel) Get(queue string, autoAck bool) (msg Delivery, ok bool, err e
 	}
 
 	if res.DeliveryTag > 0 {
-		return *(newDelivery(ch, res)), true, nil
+		if a := ch.acks.Load(); a != nil && !autoAck {
+			a.delivered(res.DeliveryTag)
+		}
+		return *(ch.decodeDelivery(newDelivery(ch, res))), true, nil
 	}
 
 	return Delivery{}, false, nil
@@ -2057,9 +2381,9 @@ Channel.Get methods will not be affected by flow control.
 This method is not intended to act as window control.  Use Channel.Qos to limit
 the number of unacknowledged messages or bytes in flight instead.
 
-The server may also send us flow methods to throttle our publishings.  A well
-behaving publishing client should add a listener with Channel.NotifyFlow and
-pause its publishings when `false` is sent on that channel.
+The server may also send us flow methods to throttle our publishings.  The
+publishing methods pause on their own while the server has paused the flow, see
+Channel.FlowActive.
 
 Note: RabbitMQ prefers to use TCP push back to control flow for all channels on
 a connection, so under high volume scenarios, it's wise to open separate
@@ -2141,11 +2465,22 @@ Channel.Consume or Channel.Get.
 Ack acknowledges all message received prior to the delivery tag when multiple
 is true.
 
+When ack coalescing is enabled, the acknowledgement may be held back and sent
+later along with others, see Channel.EnableAckCoalescing.
+
 See also Delivery.Ack
 */
 func (ch *Channel) Ack(tag uint64, multiple bool) error {
 	ch.m.Lock()
 	defer ch.m.Unlock()
+	return ch.ack(tag, multiple)
+}
+
+// ack sends or holds back an acknowledgement.  The caller must hold ch.m.
+func (ch *Channel) ack(tag uint64, multiple bool) error {
+	if a := ch.acks.Load(); a != nil {
+		return a.ack(tag, multiple)
+	}
 
 	return ch.send(&basicAck{
 		DeliveryTag: tag,
@@ -2163,6 +2498,16 @@ See also Delivery.Nack
 func (ch *Channel) Nack(tag uint64, multiple, requeue bool) error {
 	ch.m.Lock()
 	defer ch.m.Unlock()
+	return ch.nack(tag, multiple, requeue)
+}
+
+// nack sends a negative acknowledgement.  The caller must hold ch.m.
+func (ch *Channel) nack(tag uint64, multiple, requeue bool) error {
+	if a := ch.acks.Load(); a != nil {
+		if err := a.beforeSettle(tag, multiple); err != nil {
+			return err
+		}
+	}
 
 	return ch.send(&basicNack{
 		DeliveryTag: tag,
@@ -2181,6 +2526,16 @@ See also Delivery.Reject
 func (ch *Channel) Reject(tag uint64, requeue bool) error {
 	ch.m.Lock()
 	defer ch.m.Unlock()
+	return ch.reject(tag, requeue)
+}
+
+// reject sends a rejection.  The caller must hold ch.m.
+func (ch *Channel) reject(tag uint64, requeue bool) error {
+	if a := ch.acks.Load(); a != nil {
+		if err := a.beforeSettle(tag, false); err != nil {
+			return err
+		}
+	}
 
 	return ch.send(&basicReject{
 		DeliveryTag: tag,
@@ -2188,6 +2543,19 @@ func (ch *Channel) Reject(tag uint64, requeue bool) error {
 	})
 }
 
+// settleDelivery runs settle, one of ack, nack or reject, while holding the
+// channel, unless the channel was recovered since the delivery arrived on its
+// incarnation, as its delivery tag would then refer to another delivery.
+func (ch *Channel) settleDelivery(incarnation uint64, settle func() error) error {
+	ch.m.Lock()
+	defer ch.m.Unlock()
+
+	if ch.incarnation.Load() != incarnation {
+		return ErrStaleDelivery
+	}
+	return settle()
+}
+
 // GetNextPublishSeqNo returns the sequence number of the next message to be
 // published, when in confirm mode.
 func (ch *Channel) GetNextPublishSeqNo() uint64 {
@@ -2224,6 +2592,10 @@ func (ch *Channel) closeResources(err error) {
 			close(c)
 		}
 
+		for _, c := range ch.resubscribes {
+			close(c)
+		}
+
 		for _, c := range ch.recoveryCancels {
 			close(c)
 		}
@@ -2235,6 +2607,7 @@ func (ch *Channel) closeResources(err error) {
 		ch.closes = nil
 		ch.returns = nil
 		ch.cancels = nil
+		ch.resubscribes = nil
 
 		if ch.confirms != nil {
 			ch.confirms.Close()
@@ -2506,6 +2879,15 @@ func (ch *Channel) resetState() {
 	ch.close = make(chan struct{})
 	ch.rpc = make(chan message)
 
+	// A new channel starts with its flow active.
+	ch.setFlow(true)
+
+	// Delivery tags start over.
+	ch.incarnation.Add(1)
+	if a := ch.acks.Load(); a != nil {
+		a.reset()
+	}
+
 	if ch.confirms != nil {
 		ch.confirms.reset()
 	}
@@ -5,6 +5,9 @@
 package amqp091
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -233,3 +236,264 @@ func TestReconnectChannelAbortsWhenCloseWonTheRace(t *testing.T) {
 		t.Fatal("reconnectChannel() did not return within 2s — it did not abort on closeInit and is stuck in its retry loop")
 	}
 }
+
+// manualRecovery leaves recovery to the test.
+type manualRecovery struct{}
+
+func (manualRecovery) OnConnectionClose(*Connection, *Error) {}
+func (manualRecovery) OnChannelClose(*Channel, *Error)       {}
+
+// openWaitForRecoveryChannel opens a channel with Recovery.WaitForRecovery set
+// and simulates it starting to recover.
+func openWaitForRecoveryChannel(t *testing.T, serve func(srv *server)) *Channel {
+	t.Helper()
+
+	config := defaultConfig()
+	config.Recovery = &Recovery{
+		ReconnectionConfig: &ReconnectionConfig{MaxRetryCount: 1},
+		ConnectionRecovery: manualRecovery{},
+		WaitForRecovery:    true,
+	}
+	ch := openServedChannel(t, config, serve)
+
+	ch.setClosed()
+	ch.lifeCycle.SetState(StateReconnecting, nil)
+
+	return ch
+}
+
+func TestPublishWaitsForRecovery(t *testing.T) {
+	published := make(chan *basicPublish, 1)
+	ch := openWaitForRecoveryChannel(t, func(srv *server) {
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- ch.PublishWithContext(context.TODO(), "", "q", false, false, Publishing{Body: []byte("waited")})
+	}()
+
+	select {
+	case err := <-errs:
+		t.Fatalf("expected publish to wait for recovery, returned: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ch.closed.Store(false)
+	ch.lifeCycle.SetState(StateOpen, nil)
+
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected publish error after recovery: %v", err)
+	}
+	if pub := <-published; string(pub.Body) != "waited" {
+		t.Errorf("unexpected publishing: %q", pub.Body)
+	}
+}
+
+func TestPublishWaitForRecoveryBoundedByContext(t *testing.T) {
+	ch := openWaitForRecoveryChannel(t, func(srv *server) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", "q", false, false, Publishing{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context error, got: %v", err)
+	}
+}
+
+func TestConsumeWaitForRecoveryFailsWhenClosed(t *testing.T) {
+	ch := openWaitForRecoveryChannel(t, func(srv *server) {})
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := ch.ConsumeWithContext(context.TODO(), "q", "", false, false, false, false, nil)
+		errs <- err
+	}()
+
+	terminal := &Error{Code: ChannelError, Reason: "recovery exhausted"}
+	ch.lifeCycle.SetState(StateClosed, terminal)
+
+	select {
+	case err := <-errs:
+		if err != terminal {
+			t.Fatalf("expected the terminal error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected consume to fail once the channel is closed")
+	}
+}
+
+// openFlowChannel opens a channel whose flow the server pauses before serve
+// is called.
+func openFlowChannel(t *testing.T, config Config, serve func(srv *server)) *Channel {
+	t.Helper()
+
+	paused := make(chan struct{})
+	ch := openServedChannel(t, config, func(srv *server) {
+		srv.send(1, &channelFlow{Active: false})
+		srv.recv(1, &channelFlowOk{})
+		close(paused)
+
+		serve(srv)
+	})
+
+	<-paused
+	if ch.FlowActive() {
+		t.Fatal("expected the flow to be paused")
+	}
+	return ch
+}
+
+func TestPublishWaitsForFlowToResume(t *testing.T) {
+	resume := make(chan struct{})
+	published := make(chan *basicPublish, 1)
+
+	ch := openFlowChannel(t, defaultConfig(), func(srv *server) {
+		<-resume
+		srv.send(1, &channelFlow{Active: true})
+		srv.recv(1, &channelFlowOk{})
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+	})
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- ch.PublishWithContext(context.TODO(), "", "q", false, false, Publishing{Body: []byte("resumed")})
+	}()
+
+	select {
+	case err := <-errs:
+		t.Fatalf("expected publish to wait for the flow to resume, returned: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(resume)
+
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+	if pub := <-published; string(pub.Body) != "resumed" {
+		t.Errorf("unexpected publishing: %q", pub.Body)
+	}
+	if !ch.FlowActive() {
+		t.Error("expected the flow to be active")
+	}
+}
+
+func TestPublishFlowTimeout(t *testing.T) {
+	closeChannel := make(chan struct{})
+
+	config := defaultConfig()
+	config.FlowControlTimeout = 20 * time.Millisecond
+
+	ch := openFlowChannel(t, config, func(srv *server) {
+		<-closeChannel
+		srv.send(1, &channelClose{ReplyCode: NotFound, ReplyText: "NOT_FOUND"})
+		srv.recv(1, &channelCloseOk{})
+	})
+
+	if err := ch.Publish("", "q", false, false, Publishing{}); err != ErrFlowTimeout {
+		t.Errorf("expected ErrFlowTimeout, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", "q", false, false, Publishing{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got: %v", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- ch.PublishWithContext(context.TODO(), "", "q", false, false, Publishing{})
+	}()
+	close(closeChannel)
+
+	select {
+	case err := <-errs:
+		if err != ErrClosed {
+			t.Errorf("expected ErrClosed once the channel closes, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting publish to fail when the channel closes")
+	}
+}
+
+func TestFlowControlTimeoutDefault(t *testing.T) {
+	for _, tc := range []struct {
+		config time.Duration
+		want   time.Duration
+	}{
+		{0, defaultFlowControlTimeout},
+		{-1, 0},
+		{time.Second, time.Second},
+	} {
+		ch := &Channel{connection: &Connection{Config: Config{FlowControlTimeout: tc.config}}}
+		if got := ch.flowControlTimeout(); got != tc.want {
+			t.Errorf("FlowControlTimeout %v: expected a timeout of %v, got: %v", tc.config, tc.want, got)
+		}
+	}
+}
+
+func TestConcurrentCallsAreSerialized(t *testing.T) {
+	const rounds = 20
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		// Keep reading requests while replying, to catch a request sent
+		// before the previous one was answered.
+		var pending atomic.Int32
+		requests := make(chan message, 2*rounds)
+		go func() {
+			for i := 0; i < 2*rounds; i++ {
+				frame, err := srv.r.ReadFrame()
+				if err != nil {
+					return
+				}
+				if pending.Add(1) > 1 {
+					t.Errorf("request %d sent before the previous one was answered", i+1)
+				}
+				requests <- frame.(*methodFrame).Method
+			}
+		}()
+
+		for i := 0; i < 2*rounds; i++ {
+			req := <-requests
+			time.Sleep(time.Millisecond)
+			pending.Add(-1)
+			switch m := req.(type) {
+			case *queueDeclare:
+				srv.send(1, &queueDeclareOk{Queue: m.Queue})
+			case *exchangeDeclare:
+				srv.send(1, &exchangeDeclareOk{})
+			default:
+				t.Errorf("unexpected method: %T", m)
+				return
+			}
+		}
+	})
+
+	errs := make(chan error, 2*rounds)
+	for i := 0; i < rounds; i++ {
+		go func() {
+			_, err := ch.QueueDeclare("q", false, false, false, false, nil)
+			errs <- err
+		}()
+		go func() {
+			errs <- ch.ExchangeDeclare("e", ExchangeDirect, false, false, false, false, nil)
+		}()
+	}
+
+	for i := 0; i < 2*rounds; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Fatalf("expected each call to receive its reply, got: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for calls")
+		}
+	}
+}
@@ -593,6 +593,69 @@ func TestDeferredConfirmations(t *testing.T) {
 	assertReceive(<-acks, 3, 4)
 }
 
+func TestDeferredConfirmationReturned(t *testing.T) {
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+
+		srv.recv(1, &basicPublish{})
+		srv.recv(1, &basicPublish{})
+
+		srv.send(1, &basicReturn{
+			ReplyCode:  NoRoute,
+			ReplyText:  "NO_ROUTE",
+			Exchange:   "",
+			RoutingKey: "missing",
+			Properties: properties{MessageId: "2"},
+			Body:       []byte("pub 2"),
+		})
+		srv.send(1, &basicAck{DeliveryTag: 2, Multiple: true})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	if err = ch.Confirm(false); err != nil {
+		t.Fatalf("channel error setting confirm mode: %v (%s)", ch, err)
+	}
+
+	routed, err := ch.PublishWithDeferredConfirmWithContext(context.TODO(), "", "q", true, false, Publishing{MessageId: "1", Body: []byte("pub 1")})
+	if err != nil {
+		t.Fatalf("failed to PublishWithDeferredConfirm: %v", err)
+	}
+	returned, err := ch.PublishWithDeferredConfirmWithContext(context.TODO(), "", "missing", true, false, Publishing{MessageId: "2", Body: []byte("pub 2")})
+	if err != nil {
+		t.Fatalf("failed to PublishWithDeferredConfirm: %v", err)
+	}
+
+	if !routed.Wait() || !returned.Wait() {
+		t.Fatal("expected both publishings to be acked")
+	}
+	if _, ok := routed.Returned(); ok {
+		t.Error("expected routed publishing not to be returned")
+	}
+	ret, ok := returned.Returned()
+	if !ok {
+		t.Fatal("expected unroutable publishing to be returned")
+	}
+	if ret.ReplyCode != NoRoute || string(ret.Body) != "pub 2" {
+		t.Errorf("unexpected return: %+v", ret)
+	}
+}
+
 func TestNotifyClosesReusedPublisherConfirmChan(t *testing.T) {
 	rwc, srv := newSession(t)
 
@@ -1017,3 +1080,217 @@ func TestPublishWithDeferredConfirmWithContext_ContextCancelled(t *testing.T) {
 	}
 
 }
+
+func TestMaxMessageSizeClosesChannelOnOversizedDelivery(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	done := make(chan struct{})
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		// The client replies as soon as it reads the header, while the server
+		// is still writing the body over the synchronous test pipe.
+		sent := make(chan struct{})
+		go func() {
+			srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, make([]byte, 64), make([]byte, 64))
+			close(sent)
+		}()
+
+		srv.recv(1, &channelClose{})
+		<-sent
+		srv.send(1, &channelCloseOk{})
+		close(done)
+	}()
+
+	cfg := defaultConfig()
+	cfg.MaxMessageSize = 100
+
+	c, err := Open(rwc, cfg)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	closes := ch.NotifyClose(make(chan *Error, 1))
+
+	deliveries, err := ch.Consume("queue", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	if err := <-closes; err != ErrMessageTooLarge {
+		t.Fatalf("expected channel to close with ErrMessageTooLarge, got: %v", err)
+	}
+
+	if _, ok := <-deliveries; ok {
+		t.Fatal("expected the oversized delivery not to be delivered")
+	}
+
+	<-done
+}
+
+func TestMaxMessageSizeDiscardsFramesUntilCloseOk(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		sent := make(chan struct{})
+		go func() {
+			srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, make([]byte, 64), make([]byte, 64))
+			close(sent)
+		}()
+
+		srv.recv(1, &channelClose{})
+		<-sent
+
+		// Sent by the server before it reads channel.close.
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2}, []byte("in flight"))
+		srv.send(1, &channelCloseOk{})
+
+		srv.channelOpen(2)
+	}()
+
+	cfg := defaultConfig()
+	cfg.MaxMessageSize = 100
+
+	c, err := Open(rwc, cfg)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	closes := ch.NotifyClose(make(chan *Error, 1))
+
+	if _, err := ch.Consume("queue", tag, false, false, false, false, nil); err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	if err := <-closes; err != ErrMessageTooLarge {
+		t.Fatalf("expected channel to close with ErrMessageTooLarge, got: %v", err)
+	}
+
+	if _, err := c.Channel(); err != nil {
+		t.Fatalf("expected the connection to stay open after frames in flight on the closing channel, got: %v", err)
+	}
+	if c.IsClosed() {
+		t.Error("expected the connection to stay open")
+	}
+}
+
+func TestMaxMessageSizeDropsOversizedDelivery(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	nacks := make(chan *basicNack, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		sent := make(chan struct{})
+		go func() {
+			srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1}, make([]byte, 64), make([]byte, 64))
+			close(sent)
+		}()
+
+		nack := &basicNack{}
+		srv.recv(1, nack)
+		nacks <- nack
+		<-sent
+
+		srv.sendChunked(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2}, []byte("small"))
+	}()
+
+	cfg := defaultConfig()
+	cfg.MaxMessageSize = 100
+	cfg.DropOversizedMessages = true
+
+	c, err := Open(rwc, cfg)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	deliveries, err := ch.Consume("queue", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	nack := <-nacks
+	if nack.DeliveryTag != 1 || nack.Multiple || nack.Requeue {
+		t.Errorf("expected a single nack without requeue for delivery 1, got: %+v", nack)
+	}
+
+	next := <-deliveries
+	if next.DeliveryTag != 2 || string(next.Body) != "small" {
+		t.Errorf("expected delivery 2 with body %q, got %d with %q", "small", next.DeliveryTag, next.Body)
+	}
+
+	if ch.IsClosed() {
+		t.Error("expected channel to stay open when dropping oversized messages")
+	}
+}
+
+func TestMaxMessageSizeRejectsOversizedPublishing(t *testing.T) {
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+	}()
+
+	cfg := defaultConfig()
+	cfg.MaxMessageSize = 10
+
+	c, err := Open(rwc, cfg)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	if err := ch.PublishWithContext(context.TODO(), "", "q", false, false, Publishing{Body: make([]byte, 11)}); err != ErrMessageTooLarge {
+		t.Errorf("expected ErrMessageTooLarge from publish, got: %v", err)
+	}
+
+	if _, err := ch.PublishStream(context.TODO(), "", "q", Publishing{}, 11, bytes.NewReader(make([]byte, 11))); err != ErrMessageTooLarge {
+		t.Errorf("expected ErrMessageTooLarge from publish stream, got: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Codec compresses and decompresses message bodies for a content encoding.
+// Codecs are registered with RegisterCodec under the ContentEncoding value they
+// produce and are used by the Compression settings of a Config.
+type Codec interface {
+	// NewWriter returns a writer that encodes everything written to it into
+	// w.  Close must flush any buffered data but must not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader that decodes the data read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Content encodings of the built-in codecs.  Following HTTP, "deflate" is the
+// zlib format described in RFC 1950.
+const (
+	ContentEncodingGzip    = "gzip"
+	ContentEncodingDeflate = "deflate"
+)
+
+var (
+	codecsM sync.RWMutex
+	codecs  = map[string]Codec{
+		ContentEncodingGzip:    gzipCodec{},
+		ContentEncodingDeflate: deflateCodec{},
+	}
+)
+
+// RegisterCodec makes a Codec available for the given content encoding,
+// replacing any codec previously registered for it, including the built-in
+// gzip and deflate codecs.  It is safe to call concurrently with publishing
+// and consuming.
+func RegisterCodec(encoding string, codec Codec) {
+	codecsM.Lock()
+	defer codecsM.Unlock()
+	codecs[encoding] = codec
+}
+
+// LookupCodec returns the Codec registered for the given content encoding.
+func LookupCodec(encoding string) (Codec, bool) {
+	codecsM.RLock()
+	defer codecsM.RUnlock()
+	codec, ok := codecs[encoding]
+	return codec, ok
+}
+
+// Compression configures transparent compression of message bodies for a
+// Connection's channels.
+//
+// Publishings with an empty ContentEncoding and a body of at least Threshold
+// bytes are compressed with the codec registered for Encoding, and their
+// ContentEncoding is set accordingly.  Publishings that already have a
+// ContentEncoding are sent as they are.  Leave Encoding empty to only
+// decompress.
+//
+// Deliveries whose ContentEncoding has a registered codec are decompressed
+// before they reach the application.  Their ContentEncoding is cleared and
+// the original value is kept in Delivery.OriginalContentEncoding.  A delivery
+// that cannot be decompressed, or whose decompressed body would exceed
+// Config.MaxMessageSize, is delivered unchanged.
+//
+// Bodies sent with Channel.PublishStream and streamed to consumers through
+// Channel.SetBodyStreamThreshold are never compressed or decompressed.
+type Compression struct {
+	Encoding  string // content encoding to compress publishings with
+	Threshold int    // minimum body size in bytes to compress
+}
+
+// encode compresses the publishing's body when it qualifies for compression.
+func (c *Compression) encode(msg *Publishing) error {
+	if c == nil || c.Encoding == "" || msg.ContentEncoding != "" || len(msg.Body) < c.Threshold {
+		return nil
+	}
+
+	codec, ok := LookupCodec(c.Encoding)
+	if !ok {
+		return fmt.Errorf("amqp: no codec registered for content encoding %q", c.Encoding)
+	}
+
+	var buf bytes.Buffer
+	w, err := codec.NewWriter(&buf)
+	if err != nil {
+		return fmt.Errorf("amqp: compressing publishing with %q: %w", c.Encoding, err)
+	}
+	if _, err := w.Write(msg.Body); err != nil {
+		return fmt.Errorf("amqp: compressing publishing with %q: %w", c.Encoding, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("amqp: compressing publishing with %q: %w", c.Encoding, err)
+	}
+
+	msg.Body = buf.Bytes()
+	msg.ContentEncoding = c.Encoding
+	return nil
+}
+
+// decode decompresses the delivery's body when its content encoding has a
+// registered codec.  max limits the decompressed size when positive.
+func (c *Compression) decode(d *Delivery, max int) error {
+	if c == nil || d.ContentEncoding == "" || d.BodyReader != nil {
+		return nil
+	}
+
+	codec, ok := LookupCodec(d.ContentEncoding)
+	if !ok {
+		return nil
+	}
+
+	r, err := codec.NewReader(bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var src io.Reader = r
+	if max > 0 {
+		src = io.LimitReader(r, int64(max)+1)
+	}
+
+	body, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if max > 0 && len(body) > max {
+		return ErrMessageTooLarge
+	}
+
+	d.Body = body
+	d.OriginalContentEncoding = d.ContentEncoding
+	d.ContentEncoding = ""
+	return nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type deflateCodec struct{}
+
+func (deflateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func (deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"key":"value"}`), 100)
+
+	for _, encoding := range []string{ContentEncodingGzip, ContentEncodingDeflate} {
+		t.Run(encoding, func(t *testing.T) {
+			c := &Compression{Encoding: encoding, Threshold: 100}
+
+			msg := Publishing{Body: body}
+			if err := c.encode(&msg); err != nil {
+				t.Fatalf("unexpected error compressing: %v", err)
+			}
+			if msg.ContentEncoding != encoding {
+				t.Errorf("expected content encoding %q, got %q", encoding, msg.ContentEncoding)
+			}
+			if len(msg.Body) >= len(body) {
+				t.Errorf("expected body to shrink from %d bytes, got %d", len(body), len(msg.Body))
+			}
+
+			d := Delivery{ContentEncoding: msg.ContentEncoding, Body: msg.Body}
+			if err := c.decode(&d, 0); err != nil {
+				t.Fatalf("unexpected error decompressing: %v", err)
+			}
+			if !bytes.Equal(d.Body, body) {
+				t.Error("expected decompressed body to match the original")
+			}
+			if d.ContentEncoding != "" || d.OriginalContentEncoding != encoding {
+				t.Errorf("expected encoding to move to OriginalContentEncoding, got %q and %q", d.ContentEncoding, d.OriginalContentEncoding)
+			}
+		})
+	}
+}
+
+func TestCompressionSkipsSmallAndEncodedPublishings(t *testing.T) {
+	c := &Compression{Encoding: ContentEncodingGzip, Threshold: 100}
+
+	small := Publishing{Body: []byte("small")}
+	if err := c.encode(&small); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if small.ContentEncoding != "" || string(small.Body) != "small" {
+		t.Errorf("expected body under the threshold to be sent as is, got %q with %q", small.Body, small.ContentEncoding)
+	}
+
+	encoded := Publishing{ContentEncoding: "br", Body: make([]byte, 200)}
+	if err := c.encode(&encoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if encoded.ContentEncoding != "br" || len(encoded.Body) != 200 {
+		t.Errorf("expected an already encoded body to be sent as is, got %d bytes with %q", len(encoded.Body), encoded.ContentEncoding)
+	}
+}
+
+func TestCompressionDecodeLimitedByMaxMessageSize(t *testing.T) {
+	c := &Compression{Encoding: ContentEncodingGzip}
+
+	msg := Publishing{Body: make([]byte, 10000)}
+	if err := c.encode(&msg); err != nil {
+		t.Fatalf("unexpected error compressing: %v", err)
+	}
+
+	d := Delivery{ContentEncoding: msg.ContentEncoding, Body: msg.Body}
+	if err := c.decode(&d, 1000); err != ErrMessageTooLarge {
+		t.Fatalf("expected ErrMessageTooLarge, got: %v", err)
+	}
+	if d.ContentEncoding != ContentEncodingGzip || !bytes.Equal(d.Body, msg.Body) {
+		t.Error("expected the delivery to be left unchanged")
+	}
+}
+
+type upperCodec struct{}
+
+type upperWriter struct{ w io.Writer }
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (upperWriter) Close() error { return nil }
+
+func (upperCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return upperWriter{w}, nil
+}
+
+func (upperCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(strings.ToLower(string(b)))), nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	const encoding = "x-upper"
+
+	if _, ok := LookupCodec(encoding); ok {
+		t.Fatalf("expected no codec registered for %q", encoding)
+	}
+
+	RegisterCodec(encoding, upperCodec{})
+	defer func() {
+		codecsM.Lock()
+		delete(codecs, encoding)
+		codecsM.Unlock()
+	}()
+
+	c := &Compression{Encoding: encoding}
+	msg := Publishing{Body: []byte("hello")}
+	if err := c.encode(&msg); err != nil {
+		t.Fatalf("unexpected error encoding: %v", err)
+	}
+	if string(msg.Body) != "HELLO" {
+		t.Errorf("expected registered codec to be used, got %q", msg.Body)
+	}
+
+	d := Delivery{ContentEncoding: encoding, Body: msg.Body}
+	if err := c.decode(&d, 0); err != nil {
+		t.Fatalf("unexpected error decoding: %v", err)
+	}
+	if string(d.Body) != "hello" {
+		t.Errorf("expected registered codec to be used, got %q", d.Body)
+	}
+}
+
+func TestCompressionPublishAndConsume(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	body := bytes.Repeat([]byte("compress me "), 100)
+	published := make(chan *basicPublish, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		// Deliver the publishing back as it was received.
+		srv.send(1, &basicDeliver{
+			ConsumerTag: tag,
+			DeliveryTag: 1,
+			Properties:  pub.Properties,
+			Body:        pub.Body,
+		})
+	}()
+
+	cfg := defaultConfig()
+	cfg.Compression = &Compression{Encoding: ContentEncodingGzip, Threshold: 256}
+
+	c, err := Open(rwc, cfg)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	if err := ch.PublishWithContext(context.TODO(), "", "q", false, false, Publishing{Body: body}); err != nil {
+		t.Fatalf("publish error: %v", err)
+	}
+
+	pub := <-published
+	if pub.Properties.ContentEncoding != ContentEncodingGzip || bytes.Equal(pub.Body, body) {
+		t.Fatalf("expected a gzip compressed publishing, got content encoding %q", pub.Properties.ContentEncoding)
+	}
+
+	deliveries, err := ch.Consume("q", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	d := <-deliveries
+	if !bytes.Equal(d.Body, body) {
+		t.Error("expected the delivery to be decompressed")
+	}
+	if d.ContentEncoding != "" || d.OriginalContentEncoding != ContentEncodingGzip {
+		t.Errorf("expected original content encoding %q, got ContentEncoding %q and OriginalContentEncoding %q", ContentEncodingGzip, d.ContentEncoding, d.OriginalContentEncoding)
+	}
+}
@@ -7,6 +7,7 @@ package amqp091
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
@@ -19,6 +20,30 @@ type confirms struct {
 	published             uint64
 	publishedMut          sync.Mutex
 	expecting             uint64
+
+	// mandatory and returns correlate basic.return methods with the
+	// DeferredConfirmation of the mandatory publishing they belong to. The
+	// server sends the basic.return before the basic.ack of the same
+	// publishing, so returns are held until a covering ack or nack arrives.
+	returnsMut sync.Mutex
+	mandatory  map[uint64]returnRoute
+	returns    []Return
+}
+
+// returnRoute holds the fields of a mandatory publishing used to match it
+// against a basic.return.
+type returnRoute struct {
+	exchange      string
+	routingKey    string
+	messageId     string
+	correlationId string
+}
+
+func (r returnRoute) matches(ret Return) bool {
+	return r.exchange == ret.Exchange &&
+		r.routingKey == ret.RoutingKey &&
+		r.messageId == ret.MessageId &&
+		r.correlationId == ret.CorrelationId
 }
 
 // newConfirms allocates a confirms
@@ -26,6 +51,7 @@ func newConfirms() *confirms {
 	return &confirms{
 		sequencer:             map[uint64]Confirmation{},
 		deferredConfirmations: newDeferredConfirmations(),
+		mandatory:             map[uint64]returnRoute{},
 		published:             0,
 		expecting:             1,
 	}
@@ -53,9 +79,81 @@ func (c *confirms) unpublish() {
 	c.publishedMut.Lock()
 	defer c.publishedMut.Unlock()
 	c.deferredConfirmations.remove(c.published)
+
+	c.returnsMut.Lock()
+	delete(c.mandatory, c.published)
+	c.returnsMut.Unlock()
+
 	c.published--
 }
 
+// expectReturn records that the publishing with the given delivery tag was
+// sent with the mandatory flag, so a basic.return for it can be attached to
+// its DeferredConfirmation.
+func (c *confirms) expectReturn(tag uint64, route returnRoute) {
+	c.returnsMut.Lock()
+	defer c.returnsMut.Unlock()
+
+	c.mandatory[tag] = route
+}
+
+// returned holds a basic.return until the ack or nack of the publishing it
+// belongs to arrives. Returns are dropped when no mandatory publishing is
+// awaiting confirmation.
+func (c *confirms) returned(ret Return) {
+	c.returnsMut.Lock()
+	defer c.returnsMut.Unlock()
+
+	if len(c.mandatory) == 0 {
+		return
+	}
+	c.returns = append(c.returns, ret)
+}
+
+// correlateReturns attaches pending returns to the mandatory publishings
+// covered by an ack or nack of tag. Covered publishings are visited in
+// publishing order and each takes the oldest pending return with the same
+// exchange, routing key, message id and correlation id.
+func (c *confirms) correlateReturns(tag uint64, multiple bool) {
+	c.returnsMut.Lock()
+	defer c.returnsMut.Unlock()
+
+	if len(c.mandatory) == 0 {
+		return
+	}
+
+	var covered []uint64
+	if multiple {
+		for t := range c.mandatory {
+			if t <= tag {
+				covered = append(covered, t)
+			}
+		}
+		sort.Slice(covered, func(i, j int) bool { return covered[i] < covered[j] })
+	} else if _, found := c.mandatory[tag]; found {
+		covered = []uint64{tag}
+	}
+
+	for _, t := range covered {
+		route := c.mandatory[t]
+		delete(c.mandatory, t)
+
+		for i, ret := range c.returns {
+			if route.matches(ret) {
+				c.deferredConfirmations.setReturn(t, ret)
+				c.returns = append(c.returns[:i], c.returns[i+1:]...)
+				break
+			}
+		}
+	}
+
+	// A return always precedes the confirmation of its publishing, so once
+	// nothing is left to confirm any remaining return cannot be matched.
+	if len(c.mandatory) == 0 {
+		c.returns = nil
+	}
+}
+
 // confirm confirms one publishing, increments the expecting delivery tag, and
 // removes bookkeeping for that delivery tag.
 func (c *confirms) confirm(confirmation Confirmation) {
@@ -83,6 +181,7 @@ func (c *confirms) One(confirmed Confirmation) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
+	c.correlateReturns(confirmed.DeliveryTag, false)
 	c.deferredConfirmations.Confirm(confirmed)
 
 	if c.expecting == confirmed.DeliveryTag {
@@ -98,6 +197,7 @@ func (c *confirms) Multiple(confirmed Confirmation) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
+	c.correlateReturns(confirmed.DeliveryTag, true)
 	c.deferredConfirmations.ConfirmMultiple(confirmed)
 
 	for c.expecting <= confirmed.DeliveryTag {
@@ -113,6 +213,7 @@ func (c *confirms) Close() error {
 	defer c.m.Unlock()
 
 	c.deferredConfirmations.Close()
+	c.clearReturns()
 
 	for _, l := range c.listeners {
 		close(l)
@@ -134,6 +235,16 @@ func (c *confirms) reset() {
 	c.expecting = 1
 	c.deferredConfirmations.Close()
 	c.sequencer = map[uint64]Confirmation{}
+	c.clearReturns()
+}
+
+// clearReturns drops all return correlation state.
+func (c *confirms) clearReturns() {
+	c.returnsMut.Lock()
+	defer c.returnsMut.Unlock()
+
+	c.mandatory = map[uint64]returnRoute{}
+	c.returns = nil
 }
 
 type deferredConfirmations struct {
@@ -195,6 +306,17 @@ func (d *deferredConfirmations) ConfirmMultiple(confirmation Confirmation) {
 	}
 }
 
+// setReturn attaches a basic.return to a pending DeferredConfirmation. It must
+// be called before the confirmation is acked or nacked.
+func (d *deferredConfirmations) setReturn(tag uint64, ret Return) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if dc, found := d.confirmations[tag]; found {
+		dc.returned = &ret
+	}
+}
+
 // Close nacks all pending DeferredConfirmations being blocked by dc.Wait().
 func (d *deferredConfirmations) Close() {
 	d.m.Lock()
@@ -231,6 +353,26 @@ func (d *DeferredConfirmation) Acked() bool {
 	return d.ack
 }
 
+// Returned reports whether the server returned the publishing as unroutable
+// before confirming it, in which case the returned Return describes it. This
+// only applies to publishings sent with the mandatory flag while the channel is
+// in confirm mode. It returns false until the confirmation has arrived, so call
+// it after Done is closed or Wait has returned.
+//
+// The server acks a returned publishing like any other, so Acked and Wait
+// report true for it even though it was not routed to any queue.
+func (d *DeferredConfirmation) Returned() (Return, bool) {
+	select {
+	case <-d.done:
+	default:
+		return Return{}, false
+	}
+	if d.returned == nil {
+		return Return{}, false
+	}
+	return *d.returned, true
+}
+
 // Wait blocks until the publisher confirmation. It returns true if the server
 // successfully received the publishing.
 func (d *DeferredConfirmation) Wait() bool {
@@ -330,3 +330,75 @@ func TestConfirmDispatchDropsNotificationOnFullListener(t *testing.T) {
 		t.Fatal("confirm blocked on full listener for more than 6 seconds")
 	}
 }
+
+func TestConfirmCorrelatesReturnWithMandatoryPublishing(t *testing.T) {
+	c := newConfirms()
+
+	dc1 := c.publish()
+	c.expectReturn(dc1.DeliveryTag, returnRoute{exchange: "ex", routingKey: "routed"})
+	dc2 := c.publish()
+	c.expectReturn(dc2.DeliveryTag, returnRoute{exchange: "ex", routingKey: "unroutable", messageId: "m2"})
+	dc3 := c.publish()
+
+	c.returned(Return{ReplyCode: NoRoute, Exchange: "ex", RoutingKey: "unroutable", MessageId: "m2"})
+	c.Multiple(Confirmation{3, true})
+
+	if _, ok := dc1.Returned(); ok {
+		t.Error("expected routed mandatory publishing not to be returned")
+	}
+	ret, ok := dc2.Returned()
+	if !ok {
+		t.Fatal("expected unroutable publishing to be returned")
+	}
+	if ret.ReplyCode != NoRoute || ret.MessageId != "m2" {
+		t.Errorf("unexpected return: %+v", ret)
+	}
+	if !dc2.Acked() {
+		t.Error("expected returned publishing to be acked")
+	}
+	if _, ok := dc3.Returned(); ok {
+		t.Error("expected non-mandatory publishing not to be returned")
+	}
+}
+
+func TestConfirmReturnWaitsForItsOwnAck(t *testing.T) {
+	c := newConfirms()
+
+	dc1 := c.publish()
+	c.expectReturn(dc1.DeliveryTag, returnRoute{exchange: "ex", routingKey: "slow"})
+	dc2 := c.publish()
+	c.expectReturn(dc2.DeliveryTag, returnRoute{exchange: "ex", routingKey: "unroutable"})
+
+	// The return for tag 2 arrives while tag 1 is still awaiting its ack.
+	c.returned(Return{Exchange: "ex", RoutingKey: "unroutable"})
+	c.One(Confirmation{1, true})
+
+	if _, ok := dc1.Returned(); ok {
+		t.Fatal("expected return not to be attached to a publishing with a different route")
+	}
+
+	c.One(Confirmation{2, true})
+	if _, ok := dc2.Returned(); !ok {
+		t.Fatal("expected return to be attached once its own ack arrives")
+	}
+}
+
+func TestConfirmReturnedBeforeConfirmationIsFalse(t *testing.T) {
+	c := newConfirms()
+
+	dc := c.publish()
+	c.expectReturn(dc.DeliveryTag, returnRoute{exchange: "ex", routingKey: "unroutable"})
+	c.returned(Return{Exchange: "ex", RoutingKey: "unroutable"})
+
+	if _, ok := dc.Returned(); ok {
+		t.Fatal("expected Returned to be false before the confirmation arrives")
+	}
+
+	c.unpublish()
+	c.returnsMut.Lock()
+	pending := len(c.mandatory)
+	c.returnsMut.Unlock()
+	if pending != 0 {
+		t.Fatalf("expected unpublish to drop the mandatory publishing, %d left", pending)
+	}
+}
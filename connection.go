@@ -54,6 +54,39 @@ type Config struct {
 	FrameSize  int           // 0 max bytes means unlimited
 	Heartbeat  time.Duration // less than 1s uses the server's interval
 
+	// MaxMessageSize limits the body size in bytes of messages received and
+	// published on the connection's channels.  0 means unlimited.
+	//
+	// A content header announcing a larger body is handled before any of the
+	// body is buffered.  By default the channel is closed with
+	// ErrMessageTooLarge.  Publishing a larger body returns ErrMessageTooLarge
+	// without sending anything.  Bodies streamed to consumers through
+	// Channel.SetBodyStreamThreshold are not buffered and are not limited.
+	MaxMessageSize int
+
+	// DropOversizedMessages changes how messages larger than MaxMessageSize
+	// are handled on receive.  Instead of closing the channel, deliveries are
+	// negatively acknowledged without requeueing, unless the consumer uses
+	// automatic acknowledgement, and their body is discarded.  Returned
+	// publishings are discarded.  A message received with Channel.Get still
+	// closes the channel.
+	DropOversizedMessages bool
+
+	// Compression enables transparent compression of published bodies and
+	// decompression of delivered bodies using the registered codecs, see
+	// Compression.  If Compression is nil, bodies are never transformed.
+	Compression *Compression
+
+	// FlowControlTimeout bounds how long Channel.Publish and
+	// Channel.PublishWithDeferredConfirm wait for the server to resume a
+	// channel's flow after pausing it with channel.flow, before failing with
+	// ErrFlowTimeout.  0 uses a default of 30 seconds, so that publishers
+	// without a context are not held forever by a server that keeps the flow
+	// paused.  A negative value waits until the flow resumes or the channel
+	// closes.  The variants taking a context wait until the context is done
+	// instead.
+	FlowControlTimeout time.Duration
+
 	// TLSClientConfig specifies the client configuration of the TLS connection
 	// when establishing a tls transport.
 	// If the URL uses an amqps scheme, then an empty tls.Config with the
@@ -1957,6 +1990,20 @@ func (c *Connection) deleteRecordedQueue(name string) {
 	}
 }
 
+// isRecordedAutoDeleteQueue reports whether queueName was recorded for
+// topology recovery as an auto-delete queue.
+func (c *Connection) isRecordedAutoDeleteQueue(queueName string) bool {
+	c.topologyM.Lock()
+	defer c.topologyM.Unlock()
+
+	for _, cfg := range c.topologyConfiguration {
+		if qc, ok := cfg.Queues[queueName]; ok && qc.AutoDelete {
+			return true
+		}
+	}
+	return false
+}
+
 // maybeDeleteRecordedAutoDeleteQueue forgets a queue from the topology store when
 // it is auto-delete and all its consumers on this connection have been cancelled.
 func (c *Connection) maybeDeleteRecordedAutoDeleteQueue(queueName string) {
@@ -1965,18 +2012,7 @@ func (c *Connection) maybeDeleteRecordedAutoDeleteQueue(queueName string) {
 	}
 
 	// Fast path: bail early if not tracked as auto-delete.
-	c.topologyM.Lock()
-	isAutoDelete := false
-	if c.topologyConfiguration != nil {
-		for _, cfg := range c.topologyConfiguration {
-			if qc, ok := cfg.Queues[queueName]; ok && qc.AutoDelete {
-				isAutoDelete = true
-				break
-			}
-		}
-	}
-	c.topologyM.Unlock()
-	if !isAutoDelete {
+	if !c.isRecordedAutoDeleteQueue(queueName) {
 		return
 	}
 
@@ -2486,6 +2522,12 @@ func (c *Connection) recoverConnectionTopology(channels []*Channel) ([]TopologyR
 		ch.consumers.Unlock()
 
 		for tag, config := range configs {
+			// A consumer paused while its buffer is full stays paused.
+			flow := ch.consumers.flowFor(tag)
+			if !flow.recovering() {
+				continue
+			}
+
 			req := &basicConsume{
 				Queue:       config.Queue,
 				ConsumerTag: tag,
@@ -2493,10 +2535,12 @@ func (c *Connection) recoverConnectionTopology(channels []*Channel) ([]TopologyR
 				NoAck:       config.AutoAck,
 				Exclusive:   config.Exclusive,
 				NoWait:      config.NoWait,
-				Arguments:   config.Args,
+				Arguments:   config.recoveryArgs(),
 			}
 			res := &basicConsumeOk{}
-			if err := ch.call(req, res); err != nil {
+			err := ch.call(req, res)
+			flow.recovered()
+			if err != nil {
 				Logger.Printf("failed to recover consumer for tag %s on queue %s on channel %d: %v", tag, config.Queue, ch.id, err)
 				e := TopologyRecoveryEntity{EntityType: TopologyEntityConsumer, EntityName: tag, ChannelID: ch.id, Err: err}
 				if cont, fatal := skipOrAbort(e); !cont {
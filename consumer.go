@@ -0,0 +1,362 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Handler processes a delivery received by a Consumer.  Returning nil
+// acknowledges the delivery, returning an error hands it to the consumer's
+// ErrorPolicy.  ctx is cancelled when a Shutdown of the consumer gives up
+// waiting.
+type Handler func(ctx context.Context, d Delivery) error
+
+// ErrorAction is what a Consumer does with a delivery whose handler failed.
+type ErrorAction int
+
+const (
+	// ErrorActionRequeue negatively acknowledges the delivery and requeues it.
+	ErrorActionRequeue ErrorAction = iota
+	// ErrorActionReject rejects the delivery without requeueing it, so the
+	// server dead-letters it if the queue has a dead letter exchange, and
+	// drops it otherwise.
+	ErrorActionReject
+	// ErrorActionAck acknowledges the delivery as if it had been handled.
+	ErrorActionAck
+)
+
+// ErrorPolicy chooses the ErrorAction for a delivery whose handler returned
+// err, or panicked, in which case err wraps the panic value.
+type ErrorPolicy func(d Delivery, err error) ErrorAction
+
+// RequeueOnce is the default ErrorPolicy of a Consumer.  It requeues a failed
+// delivery the first time and rejects it when it fails again after being
+// redelivered, so that a message that always fails is not redelivered forever.
+func RequeueOnce(d Delivery, err error) ErrorAction {
+	if d.Redelivered {
+		return ErrorActionReject
+	}
+	return ErrorActionRequeue
+}
+
+// ErrConsumerStarted is returned by Consumer.Start when the consumer was
+// already started.
+var ErrConsumerStarted = errors.New("amqp: consumer already started")
+
+// DefaultResubscribeDelay is the default Consumer.ResubscribeDelay.
+const DefaultResubscribeDelay = time.Second
+
+/*
+Consumer consumes a queue with a pool of goroutines calling a Handler, or in
+batches with a BatchHandler when created with NewBatchConsumer.  Consumers
+created with NewOrderedConsumer preserve the order of deliveries per key.
+
+The channel's prefetch count is set to Concurrency, so each handler goroutine
+has at most one delivery at a time.  A delivery is acknowledged when its
+handler returns nil.  When the handler returns an error or panics, OnError
+decides whether the delivery is requeued, rejected or acknowledged.
+
+When the server cancels the consumer, for example because its queue was
+deleted, the channel subscribes it again every ResubscribeDelay, until it
+succeeds or the Consumer is shut down, see Channel.SetResubscribePolicy.  When the channel closes for good, the Consumer
+stops: Done is closed and Err returns the reason.  A channel being recovered
+keeps the Consumer running, as consumers are recovered along with it.
+
+Set the exported fields before calling Start.
+*/
+type Consumer struct {
+	ch           *Channel
+	queue        string
+	handler      Handler
+	batchHandler BatchHandler
+	key          KeyFunc
+
+	// Concurrency is the number of deliveries handled at the same time.  The
+	// channel's prefetch count is set to match.  It defaults to 1.  It is
+	// ignored by batch consumers, see NewBatchConsumer, and is the number of
+	// lanes of ordered consumers, see NewOrderedConsumer.
+	Concurrency int
+
+	// BatchSize and BatchTimeout only apply to batch consumers, see
+	// NewBatchConsumer.  They default to DefaultBatchSize and
+	// DefaultBatchTimeout.
+	BatchSize    int
+	BatchTimeout time.Duration
+
+	// Prefetch only applies to ordered consumers, see NewOrderedConsumer.
+	// It defaults to DefaultLanePrefetch times Concurrency.
+	Prefetch int
+
+	// Consumer is the consumer tag, generated when empty.
+	Consumer string
+
+	// Exclusive and Args are passed to Channel.Consume.
+	Exclusive bool
+	Args      Table
+
+	// OnError decides what happens to deliveries whose handler failed.  It
+	// defaults to RequeueOnce.  Deliveries whose handler returned
+	// ErrDuplicateInProgress are requeued regardless, which a Deduplicator
+	// only returns after its InProgressDelay.
+	OnError ErrorPolicy
+
+	// ResubscribeDelay is the delay before subscribing again after the
+	// server cancelled the consumer.  It defaults to DefaultResubscribeDelay.
+	ResubscribeDelay time.Duration
+
+	m        sync.Mutex
+	started  bool
+	stopping chan struct{} // closed by Shutdown
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	done     chan struct{}
+	err      error
+}
+
+// NewConsumer returns a Consumer that handles deliveries from queue on ch with
+// handler.  The consumer should have the channel to itself.
+func NewConsumer(ch *Channel, queue string, handler Handler) *Consumer {
+	return &Consumer{
+		ch:          ch,
+		queue:       queue,
+		handler:     handler,
+		Concurrency: 1,
+		stopping:    make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start sets the channel's prefetch count, subscribes to the queue and starts
+// handling deliveries.
+func (c *Consumer) Start() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.started {
+		return ErrConsumerStarted
+	}
+
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	prefetch := concurrency
+	if c.batchHandler != nil {
+		if c.BatchSize < 1 {
+			c.BatchSize = DefaultBatchSize
+		}
+		if c.BatchTimeout <= 0 {
+			c.BatchTimeout = DefaultBatchTimeout
+		}
+		// Batches are handled one at a time and settled with a single
+		// acknowledgement covering every earlier delivery.
+		concurrency = 1
+		prefetch = c.BatchSize
+	}
+	if c.key != nil {
+		if c.Prefetch < 1 {
+			c.Prefetch = DefaultLanePrefetch * concurrency
+		}
+		prefetch = c.Prefetch
+	}
+	if c.OnError == nil {
+		c.OnError = RequeueOnce
+		if c.batchHandler != nil {
+			c.OnError = requeueAlways
+		}
+	}
+	if c.ResubscribeDelay <= 0 {
+		c.ResubscribeDelay = DefaultResubscribeDelay
+	}
+
+	if err := c.ch.Qos(prefetch, 0, false); err != nil {
+		return err
+	}
+
+	if c.Consumer == "" {
+		c.Consumer = uniqueConsumerTag()
+	}
+
+	deliveries, err := c.consume()
+	if err != nil {
+		return err
+	}
+
+	c.started = true
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	// Handler goroutines share a single lane, unless deliveries are ordered
+	// by key, in which case each has its own.
+	work := []chan Delivery{make(chan Delivery)}
+	if c.key != nil {
+		work = c.lanes(concurrency, prefetch)
+	}
+	for i := 0; i < concurrency; i++ {
+		lane := work[i%len(work)]
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			if c.batchHandler != nil {
+				c.collect(lane)
+				return
+			}
+			for d := range lane {
+				c.handle(d)
+			}
+		}()
+	}
+
+	go c.dispatch(deliveries, work)
+
+	return nil
+}
+
+/*
+Shutdown cancels the subscription and waits for the deliveries already
+received, including those prefetched but not yet handled, to be handled and
+acknowledged.  If ctx is done first, the context passed to handlers in flight
+is cancelled and ctx's error is returned.  Shutdown does not close the channel.
+*/
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.m.Lock()
+	started := c.started
+	if !c.isStopping() {
+		close(c.stopping)
+	}
+	c.m.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	cancelErr := c.ch.Cancel(c.Consumer, false)
+
+	select {
+	case <-c.done:
+		c.cancel()
+		if errors.Is(cancelErr, ErrClosed) {
+			// The deliveries were closed along with the channel.
+			return nil
+		}
+		return cancelErr
+	case <-ctx.Done():
+		c.cancel()
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the consumer has stopped and all
+// its handlers have returned, after a Shutdown or because the channel closed.
+func (c *Consumer) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the consumer stopped on its own, once Done is closed.  It
+// returns nil after a Shutdown.
+func (c *Consumer) Err() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.err
+}
+
+func (c *Consumer) consume() (<-chan Delivery, error) {
+	return c.ch.consume(context.Background(), consumerConfig{
+		Queue:     c.queue,
+		Consumer:  c.Consumer,
+		Exclusive: c.Exclusive,
+		Args:      c.Args,
+		Resubscribe: &ResubscribePolicy{
+			InitialDelay: c.ResubscribeDelay,
+			MaxDelay:     c.ResubscribeDelay,
+		},
+	})
+}
+
+func (c *Consumer) isStopping() bool {
+	select {
+	case <-c.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatch hands deliveries to the lanes of the handler goroutines.  Server
+// cancellations are handled by the channel, which subscribes again without
+// closing deliveries, see Channel.SetResubscribePolicy.
+func (c *Consumer) dispatch(deliveries <-chan Delivery, work []chan Delivery) {
+	defer func() {
+		for _, lane := range work {
+			close(lane)
+		}
+		c.wg.Wait()
+		close(c.done)
+	}()
+
+	for d := range deliveries {
+		work[c.lane(d, len(work))] <- d
+	}
+
+	if !c.isStopping() {
+		// The channel closed, or the consumer was cancelled with
+		// Channel.Cancel.
+		c.fail(ErrClosed)
+	}
+}
+
+func (c *Consumer) fail(err error) {
+	c.m.Lock()
+	c.err = err
+	c.m.Unlock()
+}
+
+// handle processes a single delivery.
+func (c *Consumer) handle(d Delivery) {
+	err := c.invoke(d)
+	if err == nil {
+		if aerr := d.Ack(false); aerr != nil {
+			Logger.Printf("error acknowledging delivery %d: %+v", d.DeliveryTag, aerr)
+		}
+		return
+	}
+
+	action := c.OnError(d, err)
+	if errors.Is(err, ErrDuplicateInProgress) {
+		// The message may still fail where it is being processed.
+		action = ErrorActionRequeue
+	}
+
+	var aerr error
+	switch action {
+	case ErrorActionAck:
+		aerr = d.Ack(false)
+	case ErrorActionReject:
+		aerr = d.Reject(false)
+	default:
+		aerr = d.Nack(false, true)
+	}
+	if aerr != nil {
+		Logger.Printf("error settling failed delivery %d: %+v", d.DeliveryTag, aerr)
+	}
+}
+
+// invoke calls the handler, turning a panic into an error.
+func (c *Consumer) invoke(d Delivery) (err error) {
+	defer recoverHandler(&err)
+	return c.handler(c.ctx, d)
+}
+
+// recoverHandler turns a panic of a handler into an error.  It is deferred by
+// the functions calling the handlers of Consumer and RPCServer.
+func recoverHandler(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("panic: %v", r)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidBufferLimit is returned by Channel.SetBufferLimit for a limit that
+// cannot be applied.
+var ErrInvalidBufferLimit = errors.New("amqp: invalid buffer limit")
+
+// BufferLimit bounds the deliveries buffered for a consumer while the
+// application does not receive them, see Channel.SetBufferLimit.
+type BufferLimit struct {
+	// Max is the number of buffered deliveries at which the consumer is
+	// paused.  It must be positive.
+	Max int
+
+	// Resume is the number of buffered deliveries at or below which a paused
+	// consumer resumes.  It must be lower than Max, and 0 resumes once the
+	// buffer is empty.
+	Resume int
+
+	// OnOverflow, when set, is called each time the consumer is paused, from
+	// the goroutine that paused it, once the consumer can be resumed again.
+	OnOverflow func(BufferOverflow)
+}
+
+// BufferOverflow reports a consumer paused because its buffer reached
+// BufferLimit.Max.
+type BufferOverflow struct {
+	Consumer string
+	Queue    string
+	Buffered int   // deliveries buffered when the limit was reached
+	Err      error // non-nil when the consumer could not be paused
+}
+
+/*
+SetBufferLimit bounds the deliveries buffered for a consumer of the channel.
+
+Deliveries are buffered without limit while the chan returned by Channel.Consume
+is not received from, which lets the memory used grow with slow handlers,
+autoAck consumers or a large prefetch count.  With a limit, the consumer is
+cancelled on the server once limit.Max deliveries are buffered, and subscribed
+again with the same consumer tag and arguments once the application has
+received enough of them to bring the buffer down to limit.Resume.  Deliveries
+already sent by the server when the consumer is paused are still buffered, so
+the buffer can briefly hold more than limit.Max deliveries.  Other consumers of
+the channel and connection are not affected.
+
+Pausing a consumer cancels it on the server, as AMQP has no way to stop the
+deliveries of a single consumer otherwise: another consumer becomes active on
+single active consumer queues, and queues declared as auto-delete would be
+deleted when their last consumer is cancelled, so that the consumer could never
+resume.  ErrInvalidBufferLimit is returned for a consumer of a queue declared
+as auto-delete on the connection and recorded for topology recovery; other
+auto-delete queues, such as those declared by another connection, must not be
+consumed with a limit.  A paused consumer is not subscribed again by channel
+recovery until it resumes.  The cancellations and subscriptions are sent as
+any other RPC on the channel, one at a time.
+
+A nil limit removes the limit, resuming the consumer if it is paused.
+ErrConsumerNotFound is returned when consumer is not consuming on the channel.
+*/
+func (ch *Channel) SetBufferLimit(consumer string, limit *BufferLimit) error {
+	if limit != nil {
+		if limit.Max <= 0 {
+			return fmt.Errorf("%w: max %d is not positive", ErrInvalidBufferLimit, limit.Max)
+		}
+		if limit.Resume < 0 || limit.Resume >= limit.Max {
+			return fmt.Errorf("%w: resume %d is not between 0 and max %d", ErrInvalidBufferLimit, limit.Resume, limit.Max)
+		}
+		l := *limit
+		limit = &l
+	}
+
+	flow := ch.consumers.flowFor(consumer)
+	if flow == nil {
+		return ErrConsumerNotFound
+	}
+
+	if config, _ := ch.consumers.configFor(consumer); limit != nil && ch.connection.isRecordedAutoDeleteQueue(config.Queue) {
+		return fmt.Errorf("%w: queue %q is auto-delete and would be deleted when the consumer is paused", ErrInvalidBufferLimit, config.Queue)
+	}
+
+	flow.limit.Store(limit)
+	if limit == nil && flow.want.Swap(false) {
+		go ch.throttle(consumer, flow, 0)
+	}
+	return nil
+}
+
+// throttle pauses or resumes the consumer with tag until it is as wanted by
+// its flow, or the consumer is cancelled.
+func (ch *Channel) throttle(tag string, flow *consumerFlow, buffered int) {
+	// OnOverflow is called once the flow is unlocked, so that it cannot hold
+	// up resuming the consumer.
+	var overflows []func()
+	defer func() {
+		for _, overflow := range overflows {
+			overflow()
+		}
+	}()
+
+	flow.m.Lock()
+	defer flow.m.Unlock()
+
+	for {
+		pause := flow.want.Load()
+		if pause == flow.paused {
+			return
+		}
+
+		config, ok := ch.consumers.configFor(tag)
+		if !ok {
+			return
+		}
+
+		var err error
+		if pause {
+			err = ch.call(&basicCancel{ConsumerTag: tag}, &basicCancelOk{})
+		} else {
+			err = ch.subscribe(tag, config)
+		}
+		if err == nil {
+			flow.paused = pause
+		}
+
+		if limit := flow.limit.Load(); pause && limit != nil && limit.OnOverflow != nil {
+			o := BufferOverflow{Consumer: tag, Queue: config.Queue, Buffered: buffered, Err: err}
+			overflows = append(overflows, func() { limit.OnOverflow(o) })
+		}
+
+		if err != nil {
+			// Recovery subscribes the consumer again, unless it should
+			// stay paused.
+			Logger.Printf("error throttling consumer %s on queue %s, paused %t: %+v", tag, config.Queue, pause, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBufferLimitPausesAndResumesConsumer(t *testing.T) {
+	const tag = "bounded"
+
+	ready := make(chan struct{})
+	resumed := make(chan *basicConsume, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		<-ready
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2})
+
+		cancel := &basicCancel{}
+		srv.recv(1, cancel)
+		if cancel.ConsumerTag != tag {
+			t.Errorf("expected the consumer to be paused, got: %+v", cancel)
+		}
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+
+		consume := &basicConsume{}
+		srv.recv(1, consume)
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+		resumed <- consume
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 3, Body: []byte("resumed")})
+	})
+
+	deliveries, err := ch.Consume("work", tag, true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	if err := ch.SetBufferLimit(tag, &BufferLimit{Max: 2, Resume: 2}); !errors.Is(err, ErrInvalidBufferLimit) {
+		t.Errorf("expected resume at max to be rejected, got: %v", err)
+	}
+	if err := ch.SetBufferLimit("unknown", &BufferLimit{Max: 2}); err != ErrConsumerNotFound {
+		t.Errorf("expected ErrConsumerNotFound for an unknown consumer, got: %v", err)
+	}
+
+	overflows := make(chan BufferOverflow, 1)
+	if err := ch.SetBufferLimit(tag, &BufferLimit{Max: 2, OnOverflow: func(o BufferOverflow) { overflows <- o }}); err != nil {
+		t.Fatalf("could not set buffer limit: %v", err)
+	}
+	close(ready)
+
+	select {
+	case o := <-overflows:
+		if o.Consumer != tag || o.Queue != "work" || o.Buffered != 2 || o.Err != nil {
+			t.Errorf("unexpected overflow: %+v", o)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the consumer to be paused")
+	}
+
+	for i, d := range receiveDeliveries(t, deliveries, 3) {
+		if d.DeliveryTag != uint64(i+1) {
+			t.Errorf("expected delivery %d, got %d", i+1, d.DeliveryTag)
+		}
+	}
+
+	consume := <-resumed
+	if consume.Queue != "work" || consume.ConsumerTag != tag || !consume.NoAck {
+		t.Errorf("expected to subscribe again as before, got: %+v", consume)
+	}
+}
+
+func TestBufferLimitResumesWhileOnOverflowBlocks(t *testing.T) {
+	const tag = "bounded"
+
+	ready := make(chan struct{})
+	resumed := make(chan struct{})
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		<-ready
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Body: []byte("overflow")})
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+		close(resumed)
+	})
+
+	deliveries, err := ch.Consume("work", tag, true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	// The callback only returns once the consumer was resumed.
+	overflowed := make(chan struct{})
+	onOverflow := func(BufferOverflow) {
+		close(overflowed)
+		<-resumed
+	}
+	if err := ch.SetBufferLimit(tag, &BufferLimit{Max: 1, OnOverflow: onOverflow}); err != nil {
+		t.Fatalf("could not set buffer limit: %v", err)
+	}
+	close(ready)
+
+	<-overflowed
+	receiveDeliveries(t, deliveries, 1)
+
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the consumer to resume while OnOverflow is running")
+	}
+}
+
+func TestBufferLimitRejectsAutoDeleteQueues(t *testing.T) {
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: "bounded"})
+	})
+
+	if _, err := ch.Consume("transient", "bounded", false, false, false, false, nil); err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	ch.connection.recordQueue(ch.id, QueueConfig{ActualName: "transient", AutoDelete: true})
+
+	if err := ch.SetBufferLimit("bounded", &BufferLimit{Max: 10}); !errors.Is(err, ErrInvalidBufferLimit) {
+		t.Errorf("expected the limit to be rejected for an auto-delete queue, got: %v", err)
+	}
+	if err := ch.SetBufferLimit("bounded", nil); err != nil {
+		t.Errorf("expected removing the limit to succeed, got: %v", err)
+	}
+}
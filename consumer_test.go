@@ -0,0 +1,233 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumerSettlesPerHandlerResult(t *testing.T) {
+	const tag = "managed"
+
+	type settled struct {
+		qos    *basicQos
+		ack    *basicAck
+		nack   *basicNack
+		reject *basicReject
+	}
+	results := make(chan settled, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r settled
+
+		r.qos = &basicQos{}
+		srv.recv(1, r.qos)
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Body: []byte("ok")})
+		r.ack = &basicAck{}
+		srv.recv(1, r.ack)
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2, Body: []byte("fail")})
+		r.nack = &basicNack{}
+		srv.recv(1, r.nack)
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 3, Redelivered: true, Body: []byte("panic")})
+		r.reject = &basicReject{}
+		srv.recv(1, r.reject)
+
+		results <- r
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	c := NewConsumer(ch, "work", func(ctx context.Context, d Delivery) error {
+		switch string(d.Body) {
+		case "fail":
+			return errors.New("failed")
+		case "panic":
+			panic("boom")
+		}
+		return nil
+	})
+	c.Concurrency = 3
+	c.Consumer = tag
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+	if err := c.Start(); err != ErrConsumerStarted {
+		t.Errorf("expected ErrConsumerStarted starting twice, got: %v", err)
+	}
+
+	r := <-results
+	if r.qos.PrefetchCount != 3 {
+		t.Errorf("expected prefetch to match the concurrency, got %d", r.qos.PrefetchCount)
+	}
+	if r.ack.DeliveryTag != 1 {
+		t.Errorf("expected delivery 1 to be acknowledged, got: %+v", r.ack)
+	}
+	if r.nack.DeliveryTag != 2 || !r.nack.Requeue {
+		t.Errorf("expected delivery 2 to be requeued, got: %+v", r.nack)
+	}
+	if r.reject.DeliveryTag != 3 || r.reject.Requeue {
+		t.Errorf("expected redelivered delivery 3 to be rejected, got: %+v", r.reject)
+	}
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("expected the consumer to be done after shutdown")
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("expected no error after shutdown, got: %v", err)
+	}
+}
+
+func TestConsumerResubscribesAfterServerCancel(t *testing.T) {
+	const tag = "managed"
+
+	acked := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicCancel{ConsumerTag: tag, NoWait: true})
+
+		// The channel checks that the queue exists before subscribing again.
+		srv.channelOpen(2)
+		srv.recv(2, &queueDeclare{})
+		srv.send(2, &queueDeclareOk{Queue: "work"})
+		srv.recv(2, &channelClose{})
+		srv.send(2, &channelCloseOk{})
+
+		consume := &basicConsume{}
+		srv.recv(1, consume)
+		if consume.Queue != "work" || consume.ConsumerTag != tag {
+			t.Errorf("expected to subscribe again with the same tag, got: %+v", consume)
+		}
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acked <- ack
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	c := NewConsumer(ch, "work", func(ctx context.Context, d Delivery) error { return nil })
+	c.Consumer = tag
+	c.ResubscribeDelay = 10 * time.Millisecond
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	select {
+	case ack := <-acked:
+		if ack.DeliveryTag != 1 {
+			t.Errorf("unexpected ack: %+v", ack)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a delivery on the new subscription to be acknowledged")
+	}
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestConsumerShutdownWaitsForHandlers(t *testing.T) {
+	const tag = "managed"
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1})
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+
+		srv.recv(1, &basicNack{})
+	})
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	c := NewConsumer(ch, "work", func(ctx context.Context, d Delivery) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	c.Consumer = tag
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected shutdown to give up waiting for the handler, got: %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the handler context to be cancelled")
+	}
+	<-c.Done()
+}
+
+func TestConsumerStopsWhenChannelCloses(t *testing.T) {
+	const tag = "managed"
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &channelClose{ReplyCode: NotFound, ReplyText: "NOT_FOUND"})
+		srv.recv(1, &channelCloseOk{})
+	})
+
+	c := NewConsumer(ch, "work", func(ctx context.Context, d Delivery) error { return nil })
+	c.Consumer = tag
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the consumer to stop when the channel closes")
+	}
+	if err := c.Err(); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got: %v", err)
+	}
+}
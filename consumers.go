@@ -44,6 +44,23 @@ type consumerConfig struct {
 	NoLocal   bool
 	NoWait    bool
 	Args      Table
+
+	// Resubscribe, when set, subscribes the consumer again after the server
+	// cancelled it, see Channel.SetResubscribePolicy.
+	Resubscribe *ResubscribePolicy
+
+	// RecoveryArgs, when set, returns the arguments to subscribe with again
+	// on recovery instead of Args, e.g. to resume a stream from the last
+	// offset processed.
+	RecoveryArgs func() Table
+}
+
+// recoveryArgs returns the arguments to re-subscribe the consumer with.
+func (c consumerConfig) recoveryArgs() Table {
+	if c.RecoveryArgs != nil {
+		return c.RecoveryArgs()
+	}
+	return c.Args
 }
 
 type consumerBuffers map[string]chan *Delivery
@@ -61,6 +78,69 @@ type consumers struct {
 	sync.Mutex // protects below
 	chans      consumerBuffers
 	configs    consumerConfigs
+	flows      map[string]*consumerFlow
+
+	// throttle pauses or resumes the consumer with tag on the server, as
+	// wanted by its flow.  It is set once by the channel.
+	throttle func(tag string, flow *consumerFlow, buffered int)
+}
+
+// consumerFlow tracks whether a consumer is paused on the server because its
+// buffer is full, see Channel.SetBufferLimit.
+type consumerFlow struct {
+	limit atomic.Pointer[BufferLimit]
+	want  atomic.Bool // the consumer should be paused
+
+	m      sync.Mutex // serializes pausing and resuming the consumer
+	paused bool       // the consumer is cancelled on the server
+}
+
+// regulate decides whether the consumer should be paused with buffered
+// deliveries, and throttles it when that changes.
+func (subs *consumers) regulate(tag string, flow *consumerFlow, buffered int) {
+	limit := flow.limit.Load()
+
+	pause := flow.want.Load()
+	switch {
+	case limit == nil:
+		pause = false
+	case buffered >= limit.Max:
+		pause = true
+	case buffered <= limit.Resume:
+		pause = false
+	}
+
+	if flow.want.Swap(pause) != pause {
+		go subs.throttle(tag, flow, buffered)
+	}
+}
+
+// recovering locks the flow while the consumer is subscribed again on
+// recovery.  It returns false without locking when the consumer should stay
+// paused instead.  flow may be nil.
+func (flow *consumerFlow) recovering() bool {
+	if flow == nil {
+		return true
+	}
+
+	flow.m.Lock()
+	if flow.want.Load() {
+		// The recovered channel has no consumer for the tag.
+		flow.paused = true
+		flow.m.Unlock()
+		return false
+	}
+	return true
+}
+
+// recovered unlocks the flow locked by recovering.
+func (flow *consumerFlow) recovered() {
+	if flow == nil {
+		return
+	}
+
+	flow.paused = false
+	flow.m.Unlock()
 }
 
 func makeConsumers() *consumers {
@@ -68,10 +148,11 @@ func makeConsumers() *consumers {
 		closed:  make(chan struct{}),
 		chans:   make(consumerBuffers),
 		configs: make(consumerConfigs),
+		flows:   make(map[string]*consumerFlow),
 	}
 }
 
-func (subs *consumers) buffer(in chan *Delivery, out chan Delivery) {
+func (subs *consumers) buffer(tag string, in chan *Delivery, out chan Delivery, flow *consumerFlow) {
 	defer close(out)
 	defer subs.Done()
 
@@ -80,6 +161,7 @@ func (subs *consumers) buffer(in chan *Delivery, out chan Delivery) {
 
 	for delivery := range in {
 		queue = append(queue, delivery)
+		subs.regulate(tag, flow, len(queue))
 
 		for len(queue) > 0 {
 			select {
@@ -90,6 +172,7 @@ func (subs *consumers) buffer(in chan *Delivery, out chan Delivery) {
 			case delivery, consuming := <-inflight:
 				if consuming {
 					queue = append(queue, delivery)
+					subs.regulate(tag, flow, len(queue))
 				} else {
 					inflight = nil
 				}
@@ -123,6 +206,7 @@ func (subs *consumers) buffer(in chan *Delivery, out chan Delivery) {
 				 */
 				queue[0] = nil
 				queue = queue[1:]
+				subs.regulate(tag, flow, len(queue))
 			}
 		}
 	}
@@ -138,11 +222,13 @@ func (subs *consumers) add(tag string, consumer chan Delivery, config consumerCo
 	}
 
 	in := make(chan *Delivery)
+	flow := &consumerFlow{}
 	subs.chans[tag] = in
 	subs.configs[tag] = config
+	subs.flows[tag] = flow
 
 	subs.Add(1)
-	go subs.buffer(in, consumer)
+	go subs.buffer(tag, in, consumer, flow)
 }
 
 func (subs *consumers) cancel(tag string) (found bool) {
@@ -154,6 +240,7 @@ func (subs *consumers) cancel(tag string) (found bool) {
 	if found {
 		delete(subs.chans, tag)
 		delete(subs.configs, tag)
+		delete(subs.flows, tag)
 		close(ch)
 	}
 
@@ -170,6 +257,29 @@ func (subs *consumers) queueForTag(tag string) (string, bool) {
 	return "", false
 }
 
+// configFor returns the configuration of the consumer with the given tag.
+func (subs *consumers) configFor(tag string) (consumerConfig, bool) {
+	subs.Lock()
+	defer subs.Unlock()
+	config, ok := subs.configs[tag]
+	return config, ok
+}
+
+// flowFor returns the flow of the consumer with the given tag, or nil.
+func (subs *consumers) flowFor(tag string) *consumerFlow {
+	subs.Lock()
+	defer subs.Unlock()
+	return subs.flows[tag]
+}
+
+// isAutoAck reports whether the consumer with the given tag uses automatic
+// acknowledgement.
+func (subs *consumers) isAutoAck(tag string) bool {
+	subs.Lock()
+	defer subs.Unlock()
+	return subs.configs[tag].AutoAck
+}
+
 // hasConsumerForQueue reports whether any consumer is registered on the given queue.
 func (subs *consumers) hasConsumerForQueue(queue string) bool {
 	subs.Lock()
@@ -207,6 +317,7 @@ func (subs *consumers) close() {
 	for tag, ch := range subs.chans {
 		delete(subs.chans, tag)
 		delete(subs.configs, tag)
+		delete(subs.flows, tag)
 		close(ch)
 	}
 
@@ -0,0 +1,185 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import "time"
+
+// Headers set by the server on dead-lettered and redelivered messages.
+const (
+	DeathHeader              = "x-death"
+	FirstDeathQueueHeader    = "x-first-death-queue"
+	FirstDeathReasonHeader   = "x-first-death-reason"
+	FirstDeathExchangeHeader = "x-first-death-exchange"
+	LastDeathQueueHeader     = "x-last-death-queue"
+	LastDeathReasonHeader    = "x-last-death-reason"
+	LastDeathExchangeHeader  = "x-last-death-exchange"
+	// DeliveryCountHeader is set by quorum queues to the number of times a
+	// message was delivered before, counting returns to the queue.
+	DeliveryCountHeader = "x-delivery-count"
+)
+
+// Reasons for a message to be dead-lettered, found in DeathRecord.Reason.
+const (
+	DeathReasonRejected      = "rejected"
+	DeathReasonExpired       = "expired"
+	DeathReasonMaxLen        = "maxlen"
+	DeathReasonDeliveryLimit = "delivery_limit"
+)
+
+// DeathRecord describes how many times, and why, a message was dead-lettered
+// from a queue.  The server keeps one record per queue and reason in the
+// x-death header.
+type DeathRecord struct {
+	Queue              string
+	Reason             string
+	Count              int64
+	Exchange           string
+	RoutingKeys        []string
+	Time               time.Time // when the message was first dead-lettered for this queue and reason
+	OriginalExpiration string    // the message's expiration before it was dead-lettered, if any
+}
+
+// DeathHistory decodes the x-death header of a delivery, most recent record
+// first.  It returns nil when the message was never dead-lettered.  Malformed
+// records are skipped.
+func (d Delivery) DeathHistory() []DeathRecord {
+	deaths, ok := d.Headers[DeathHeader].([]any)
+	if !ok {
+		return nil
+	}
+
+	var history []DeathRecord
+	for _, v := range deaths {
+		t, ok := v.(Table)
+		if !ok {
+			continue
+		}
+
+		var r DeathRecord
+		r.Queue, _ = t["queue"].(string)
+		r.Reason, _ = t["reason"].(string)
+		r.Count, _ = headerInt(t["count"])
+		r.Exchange, _ = t["exchange"].(string)
+		r.Time, _ = t["time"].(time.Time)
+		r.OriginalExpiration, _ = t["original-expiration"].(string)
+		if keys, ok := t["routing-keys"].([]any); ok {
+			for _, k := range keys {
+				if k, ok := k.(string); ok {
+					r.RoutingKeys = append(r.RoutingKeys, k)
+				}
+			}
+		}
+		history = append(history, r)
+	}
+	return history
+}
+
+// DeathCount returns the number of times the message was dead-lettered, over
+// all queues and reasons.
+func (d Delivery) DeathCount() int64 {
+	var n int64
+	for _, r := range d.DeathHistory() {
+		n += r.Count
+	}
+	return n
+}
+
+// FirstDeath returns the queue, reason and exchange of the first time the
+// message was dead-lettered, from the x-first-death-* headers.  The Count and
+// RoutingKeys of the returned record are not set.  ok is false when the
+// message was never dead-lettered.
+func (d Delivery) FirstDeath() (r DeathRecord, ok bool) {
+	return d.deathHeaders(FirstDeathQueueHeader, FirstDeathReasonHeader, FirstDeathExchangeHeader)
+}
+
+// LastDeath is like FirstDeath for the last time the message was
+// dead-lettered, from the x-last-death-* headers set by RabbitMQ 3.13 and
+// later.
+func (d Delivery) LastDeath() (r DeathRecord, ok bool) {
+	return d.deathHeaders(LastDeathQueueHeader, LastDeathReasonHeader, LastDeathExchangeHeader)
+}
+
+func (d Delivery) deathHeaders(queue, reason, exchange string) (r DeathRecord, ok bool) {
+	if r.Queue, ok = d.Headers[queue].(string); !ok {
+		return DeathRecord{}, false
+	}
+	r.Reason, _ = d.Headers[reason].(string)
+	r.Exchange, _ = d.Headers[exchange].(string)
+	return r, true
+}
+
+// DeliveryCount returns the x-delivery-count header set by quorum queues,
+// which is the number of times the message was delivered before.  ok is false
+// when the header is not set, as on the first delivery or with other queue
+// types.
+func (d Delivery) DeliveryCount() (count int64, ok bool) {
+	return headerInt(d.Headers[DeliveryCountHeader])
+}
+
+// headerInt returns an integer header value, whatever its size.
+func headerInt(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case byte:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+/*
+PoisonMessageDetector tells messages that keep failing apart from those that
+failed once, from their delivery count and dead-letter history, so that they
+can be set aside instead of being redelivered forever.
+
+A zero limit is not checked.  A detector with no limit never reports poison
+messages.
+*/
+type PoisonMessageDetector struct {
+	// MaxDeliveries is the number of deliveries after which a message is
+	// poison, from the x-delivery-count header of quorum queues.
+	MaxDeliveries int64
+
+	// MaxDeaths is the number of times a message may be dead-lettered, for
+	// any reason, before it is poison.  It suits messages retried through a
+	// dead letter exchange, as with RetryTopology.
+	MaxDeaths int64
+}
+
+// IsPoison reports whether d reached one of the detector's limits.
+func (p PoisonMessageDetector) IsPoison(d Delivery) bool {
+	if p.MaxDeliveries > 0 {
+		// x-delivery-count counts the deliveries before this one.
+		if n, ok := d.DeliveryCount(); ok && n+1 >= p.MaxDeliveries {
+			return true
+		}
+	}
+	if p.MaxDeaths > 0 && d.DeathCount() >= p.MaxDeaths {
+		return true
+	}
+	return false
+}
+
+// ErrorPolicy returns a Consumer ErrorPolicy rejecting the failed deliveries
+// that are poison and requeueing the others.
+func (p PoisonMessageDetector) ErrorPolicy() ErrorPolicy {
+	return func(d Delivery, _ error) ErrorAction {
+		if p.IsPoison(d) {
+			return ErrorActionReject
+		}
+		return ErrorActionRequeue
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func deadLetteredDelivery() Delivery {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return Delivery{Headers: Table{
+		DeathHeader: []any{
+			Table{
+				"queue":        "orders.retry.1000",
+				"reason":       DeathReasonExpired,
+				"count":        int64(2),
+				"exchange":     "orders.retry",
+				"routing-keys": []any{"orders.retry.1000"},
+				"time":         at.Add(time.Second),
+			},
+			Table{
+				"queue":               "orders",
+				"reason":              DeathReasonRejected,
+				"count":               int64(3),
+				"exchange":            "",
+				"routing-keys":        []any{"orders", "cc"},
+				"time":                at,
+				"original-expiration": "60000",
+			},
+			"malformed",
+		},
+		FirstDeathQueueHeader:    "orders",
+		FirstDeathReasonHeader:   DeathReasonRejected,
+		FirstDeathExchangeHeader: "",
+	}}
+}
+
+func TestDeliveryDeathHistory(t *testing.T) {
+	d := deadLetteredDelivery()
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []DeathRecord{
+		{
+			Queue:       "orders.retry.1000",
+			Reason:      DeathReasonExpired,
+			Count:       2,
+			Exchange:    "orders.retry",
+			RoutingKeys: []string{"orders.retry.1000"},
+			Time:        at.Add(time.Second),
+		},
+		{
+			Queue:              "orders",
+			Reason:             DeathReasonRejected,
+			Count:              3,
+			RoutingKeys:        []string{"orders", "cc"},
+			Time:               at,
+			OriginalExpiration: "60000",
+		},
+	}
+	if got := d.DeathHistory(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected death history:\n got: %+v\nwant: %+v", got, want)
+	}
+	if got := d.DeathCount(); got != 5 {
+		t.Errorf("expected 5 deaths, got %d", got)
+	}
+
+	first, ok := d.FirstDeath()
+	if !ok || first.Queue != "orders" || first.Reason != DeathReasonRejected {
+		t.Errorf("unexpected first death: %+v, %v", first, ok)
+	}
+	if _, ok := d.LastDeath(); ok {
+		t.Error("expected no last death without x-last-death headers")
+	}
+
+	var never Delivery
+	if never.DeathHistory() != nil || never.DeathCount() != 0 {
+		t.Error("expected no death history for a message never dead-lettered")
+	}
+	if _, ok := never.FirstDeath(); ok {
+		t.Error("expected no first death for a message never dead-lettered")
+	}
+}
+
+func TestDeliveryDeathHistoryFromWire(t *testing.T) {
+	d := deadLetteredDelivery()
+	d.Headers[DeathHeader] = d.Headers[DeathHeader].([]any)[:2]
+
+	var buf bytes.Buffer
+	if err := writeTable(&buf, d.Headers); err != nil {
+		t.Fatalf("could not write headers: %v", err)
+	}
+	headers, err := readTable(&buf)
+	if err != nil {
+		t.Fatalf("could not read headers: %v", err)
+	}
+
+	got := Delivery{Headers: headers}.DeathHistory()
+	for i := range got {
+		// Timestamps are decoded in the local time zone.
+		got[i].Time = got[i].Time.UTC()
+	}
+	if !reflect.DeepEqual(got, d.DeathHistory()) {
+		t.Errorf("expected the decoded x-death header to match:\n got: %+v\nwant: %+v", got, d.DeathHistory())
+	}
+}
+
+func TestDeliveryCount(t *testing.T) {
+	if _, ok := (Delivery{}).DeliveryCount(); ok {
+		t.Error("expected no delivery count on a first delivery")
+	}
+
+	for _, v := range []any{int64(4), int32(4), int16(4), int8(4), uint16(4)} {
+		d := Delivery{Headers: Table{DeliveryCountHeader: v}}
+		if n, ok := d.DeliveryCount(); !ok || n != 4 {
+			t.Errorf("expected a delivery count of 4 from %T, got %d, %v", v, n, ok)
+		}
+	}
+}
+
+func TestPoisonMessageDetector(t *testing.T) {
+	delivered := func(n int64) Delivery {
+		return Delivery{Headers: Table{DeliveryCountHeader: n}}
+	}
+
+	p := PoisonMessageDetector{MaxDeliveries: 3}
+	if p.IsPoison(Delivery{}) || p.IsPoison(delivered(1)) {
+		t.Error("expected messages delivered less than 3 times not to be poison")
+	}
+	if !p.IsPoison(delivered(2)) {
+		t.Error("expected a message on its third delivery to be poison")
+	}
+
+	p = PoisonMessageDetector{MaxDeaths: 5}
+	if !p.IsPoison(deadLetteredDelivery()) {
+		t.Error("expected a message dead-lettered 5 times to be poison")
+	}
+	p.MaxDeaths = 6
+	if p.IsPoison(deadLetteredDelivery()) {
+		t.Error("expected a message dead-lettered 5 times not to be poison with a limit of 6")
+	}
+
+	if (PoisonMessageDetector{}).IsPoison(delivered(100)) {
+		t.Error("expected a detector without limits never to report poison")
+	}
+
+	policy := PoisonMessageDetector{MaxDeliveries: 3}.ErrorPolicy()
+	if got := policy(delivered(2), nil); got != ErrorActionReject {
+		t.Errorf("expected poison messages to be rejected, got %v", got)
+	}
+	if got := policy(delivered(0), nil); got != ErrorActionRequeue {
+		t.Errorf("expected other messages to be requeued, got %v", got)
+	}
+}
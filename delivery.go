@@ -7,11 +7,19 @@ package amqp091
 
 import (
 	"errors"
+	"io"
 	"time"
 )
 
 var ErrDeliveryNotInitialized = errors.New("delivery not initialized. Channel is probably closed")
 
+// ErrStaleDelivery is returned when acknowledging a delivery received before
+// its channel was recovered.  Delivery tags start over on the recovered channel,
+// so the acknowledgement is not sent, as it would settle another message or
+// close the channel with PRECONDITION_FAILED.  The server requeues the message
+// on its own when the previous channel is gone.
+var ErrStaleDelivery = errors.New("amqp: delivery is from before the channel was recovered")
+
 // Acknowledger notifies the server of successful or failed consumption of
 // deliveries via identifier found in the Delivery.DeliveryTag field.
 //
@@ -56,6 +64,23 @@ type Delivery struct {
 	RoutingKey  string // basic.publish routing key
 
 	Body []byte
+
+	// OriginalContentEncoding is the ContentEncoding the body was delivered
+	// with when it was decompressed according to Config.Compression, in which
+	// case ContentEncoding is empty.
+	OriginalContentEncoding string
+
+	// BodyReader is set instead of Body when the delivery's body is larger
+	// than the threshold set with Channel.SetBodyStreamThreshold.  It yields
+	// the body as it arrives from the server and must be read to EOF or
+	// closed, as frames for the channel's connection are not received while
+	// the body is waiting to be read.
+	BodyReader io.ReadCloser
+
+	// channel and incarnation tell the channel incarnation the delivery
+	// arrived on, to detect stale deliveries.
+	channel     *Channel
+	incarnation uint64
 }
 
 func newDelivery(channel *Channel, msg messageWithContent) *Delivery {
@@ -79,6 +104,9 @@ func newDelivery(channel *Channel, msg messageWithContent) *Delivery {
 		AppId:           props.AppId,
 
 		Body: body,
+
+		channel:     channel,
+		incarnation: channel.incarnation.Load(),
 	}
 
 	// Properties for the delivery types
@@ -115,7 +143,8 @@ on the same channel will be acknowledged.  This is useful for batch processing
 of deliveries.
 
 An error will indicate that the acknowledge could not be delivered to the
-channel it was sent from.
+channel it was sent from.  ErrStaleDelivery is returned without acknowledging
+when the channel was recovered since the delivery arrived.
 
 Either Delivery.Ack, Delivery.Reject or Delivery.Nack must be called for every
 delivery that is not automatically acknowledged.
@@ -124,6 +153,14 @@ func (d Delivery) Ack(multiple bool) error {
 	if d.Acknowledger == nil {
 		return ErrDeliveryNotInitialized
 	}
+	if d.settledByChannel() {
+		return d.channel.settleDelivery(d.incarnation, func() error {
+			return d.channel.ack(d.DeliveryTag, multiple)
+		})
+	}
+	if d.Stale() {
+		return ErrStaleDelivery
+	}
 	return d.Acknowledger.Ack(d.DeliveryTag, multiple)
 }
 
@@ -144,6 +181,14 @@ func (d Delivery) Reject(requeue bool) error {
 	if d.Acknowledger == nil {
 		return ErrDeliveryNotInitialized
 	}
+	if d.settledByChannel() {
+		return d.channel.settleDelivery(d.incarnation, func() error {
+			return d.channel.reject(d.DeliveryTag, requeue)
+		})
+	}
+	if d.Stale() {
+		return ErrStaleDelivery
+	}
 	return d.Acknowledger.Reject(d.DeliveryTag, requeue)
 }
 
@@ -169,5 +214,28 @@ func (d Delivery) Nack(multiple, requeue bool) error {
 	if d.Acknowledger == nil {
 		return ErrDeliveryNotInitialized
 	}
+	if d.settledByChannel() {
+		return d.channel.settleDelivery(d.incarnation, func() error {
+			return d.channel.nack(d.DeliveryTag, multiple, requeue)
+		})
+	}
+	if d.Stale() {
+		return ErrStaleDelivery
+	}
 	return d.Acknowledger.Nack(d.DeliveryTag, multiple, requeue)
 }
+
+// settledByChannel reports whether the delivery is settled by the channel it
+// arrived on, which then checks that it is not stale while sending.  Other
+// Acknowledgers are only checked before being called.
+func (d Delivery) settledByChannel() bool {
+	ch, ok := d.Acknowledger.(*Channel)
+	return ok && ch != nil && ch == d.channel
+}
+
+// Stale reports whether the delivery arrived on its channel before the channel
+// was recovered, in which case its delivery tag is no longer valid and
+// Delivery.Ack, Delivery.Nack and Delivery.Reject return ErrStaleDelivery.
+func (d Delivery) Stale() bool {
+	return d.channel != nil && d.channel.incarnation.Load() != d.incarnation
+}
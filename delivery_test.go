@@ -9,6 +9,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func shouldNotPanic(t *testing.T) {
@@ -64,3 +65,95 @@ func TestRejectZeroValueAcknowledgerDoesNotPanic(t *testing.T) {
 		t.Errorf("expected '%s' got '%s'", expectedErrMessage, err)
 	}
 }
+
+func TestStaleDeliveryIsNotAcknowledgedAfterRecovery(t *testing.T) {
+	recovered := make(chan struct{})
+	acks := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: "c"})
+		srv.send(1, &basicDeliver{ConsumerTag: "c", DeliveryTag: 5})
+
+		<-recovered
+		srv.channelOpen(1)
+
+		// Delivery tags start over on the recovered channel.
+		srv.send(1, &basicDeliver{ConsumerTag: "c", DeliveryTag: 1})
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acks <- ack
+	})
+
+	deliveries, err := ch.Consume("q", "c", false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	before := receiveDeliveries(t, deliveries, 1)[0]
+	if before.Stale() {
+		t.Fatal("expected the delivery to be current before recovery")
+	}
+
+	ch.reconnecting.Lock()
+	go func() {
+		defer ch.reconnecting.Unlock()
+		close(recovered)
+		if _, err := ch.openChannelSession(); err != nil {
+			t.Errorf("could not recover channel: %v", err)
+		}
+	}()
+
+	after := receiveDeliveries(t, deliveries, 1)[0]
+
+	if !before.Stale() {
+		t.Error("expected the delivery from before recovery to be stale")
+	}
+	for name, settle := range map[string]func() error{
+		"ack":    func() error { return before.Ack(false) },
+		"nack":   func() error { return before.Nack(false, true) },
+		"reject": func() error { return before.Reject(true) },
+	} {
+		if err := settle(); !errors.Is(err, ErrStaleDelivery) {
+			t.Errorf("expected %s of a stale delivery to return ErrStaleDelivery, got: %v", name, err)
+		}
+	}
+
+	if after.Stale() {
+		t.Error("expected the delivery from the recovered channel to be current")
+	}
+	if err := after.Ack(false); err != nil {
+		t.Fatalf("unexpected ack error: %v", err)
+	}
+	if ack := <-acks; ack.DeliveryTag != 1 {
+		t.Errorf("expected only the current delivery to be acknowledged, got: %+v", ack)
+	}
+}
+
+func TestStaleDeliveryIsCheckedWhileHoldingTheChannel(t *testing.T) {
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: "c"})
+		srv.send(1, &basicDeliver{ConsumerTag: "c", DeliveryTag: 5, Body: []byte("before")})
+	})
+
+	deliveries, err := ch.Consume("q", "c", false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	d := receiveDeliveries(t, deliveries, 1)[0]
+
+	// The channel is recovered while the acknowledgement waits for it.
+	ch.m.Lock()
+	acked := make(chan error, 1)
+	go func() {
+		acked <- d.Ack(false)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ch.incarnation.Add(1)
+	ch.m.Unlock()
+
+	if err := <-acked; !errors.Is(err, ErrStaleDelivery) {
+		t.Errorf("expected ErrStaleDelivery for a delivery acknowledged across a recovery, got: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateInProgress is returned by handlers wrapped by a Deduplicator for
+// a message that is still being processed, for example by a handler that
+// received it before the channel was recovered, once InProgressDelay has
+// passed without the processing ending.  Consumers requeue such deliveries
+// whatever their ErrorPolicy, so that the message is processed again if the
+// first processing fails.
+var ErrDuplicateInProgress = errors.New("amqp: duplicate message is still being processed")
+
+// DedupState is the state of a message recorded in a DedupStore.
+type DedupState int
+
+const (
+	// DedupNew is returned by DedupStore.Begin when the message was not
+	// recorded yet.
+	DedupNew DedupState = iota
+	// DedupInProgress is the state of a message being processed.
+	DedupInProgress
+	// DedupDone is the state of a message processed successfully.
+	DedupDone
+)
+
+// Default Deduplicator settings.
+const (
+	DefaultDedupTTL             = time.Hour
+	DefaultDedupProcessingTTL   = 5 * time.Minute
+	DefaultDedupInProgressDelay = time.Second
+)
+
+// DedupStore records the messages processed by a Deduplicator.  Records
+// expire after their ttl, so that a message whose processing was interrupted
+// by a crash is eventually processed again.
+type DedupStore interface {
+	// Begin records key as in progress for ttl and returns DedupNew, unless
+	// key is recorded already, in which case it returns its state and leaves
+	// it unchanged.  It must be atomic.
+	Begin(ctx context.Context, key string, ttl time.Duration) (DedupState, error)
+	// Mark records key in state for ttl, whether it was recorded or not.
+	Mark(ctx context.Context, key string, state DedupState, ttl time.Duration) error
+	// Forget removes the record of key.
+	Forget(ctx context.Context, key string) error
+}
+
+// KeyByMessageId is a KeyFunc identifying messages by their MessageId, the
+// default of a Deduplicator.
+func KeyByMessageId(d Delivery) string {
+	return d.MessageId
+}
+
+/*
+Deduplicator makes handlers idempotent: a message processed successfully is
+not processed again when it is redelivered, as after a recovery or being
+requeued while a previous delivery was processed.
+
+Messages are identified by Key, and their processing recorded in Store:
+
+  - A message is recorded as in progress for ProcessingTTL before it is handed
+    to the handler, and as done for TTL once the handler returns nil.
+  - When the handler fails or panics, the record is forgotten, so that the
+    message is processed again when redelivered.
+  - A redelivered message already done is acknowledged without calling the
+    handler: the wrapped handler returns nil.  One still in progress is looked
+    up again after InProgressDelay, and makes the wrapped handler return
+    ErrDuplicateInProgress if it is still in progress then.  The delay keeps
+    a requeued duplicate from being redelivered in a tight loop while the
+    first delivery is processed.
+
+Deliveries that are not redelivered are new to the queue, so their record is
+not looked up, unless CheckFirstDeliveries is set to also catch messages
+published twice.  Deliveries without a key are handed to the handler as is.
+
+Set the fields before calling Wrap.
+*/
+type Deduplicator struct {
+	Store DedupStore
+
+	// Key identifies messages.  It defaults to KeyByMessageId.
+	Key KeyFunc
+
+	// TTL is how long processed messages are remembered.  It defaults to
+	// DefaultDedupTTL.
+	TTL time.Duration
+
+	// ProcessingTTL is how long a message being processed is protected
+	// from duplicates, which should exceed the time its handler takes.  It
+	// defaults to DefaultDedupProcessingTTL.
+	ProcessingTTL time.Duration
+
+	// InProgressDelay is how long a delivery of a message in progress
+	// waits for the processing to end before ErrDuplicateInProgress is
+	// returned.  It defaults to DefaultDedupInProgressDelay, a negative
+	// value returns the error straight away.
+	InProgressDelay time.Duration
+
+	// CheckFirstDeliveries also looks up deliveries that are not
+	// redelivered.
+	CheckFirstDeliveries bool
+}
+
+// NewDeduplicator returns a Deduplicator recording messages in store.
+func NewDeduplicator(store DedupStore) *Deduplicator {
+	return &Deduplicator{
+		Store:           store,
+		Key:             KeyByMessageId,
+		TTL:             DefaultDedupTTL,
+		ProcessingTTL:   DefaultDedupProcessingTTL,
+		InProgressDelay: DefaultDedupInProgressDelay,
+	}
+}
+
+// Wrap returns a Handler deduplicating the deliveries handed to handler.
+func (dd *Deduplicator) Wrap(handler Handler) Handler {
+	key := dd.Key
+	if key == nil {
+		key = KeyByMessageId
+	}
+	ttl := dd.TTL
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	processingTTL := dd.ProcessingTTL
+	if processingTTL <= 0 {
+		processingTTL = DefaultDedupProcessingTTL
+	}
+	inProgressDelay := dd.InProgressDelay
+	if inProgressDelay == 0 {
+		inProgressDelay = DefaultDedupInProgressDelay
+	}
+
+	return func(ctx context.Context, d Delivery) error {
+		k := key(d)
+		if k == "" {
+			return handler(ctx, d)
+		}
+
+		if d.Redelivered || dd.CheckFirstDeliveries {
+			state, err := dd.Store.Begin(ctx, k, processingTTL)
+			if err == nil && state == DedupInProgress && inProgressDelay > 0 {
+				// Hold the delivery rather than requeueing it straight
+				// away, the processing may end meanwhile.
+				select {
+				case <-time.After(inProgressDelay):
+					state, err = dd.Store.Begin(ctx, k, processingTTL)
+				case <-ctx.Done():
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("deduplicating message %s: %w", k, err)
+			}
+			switch state {
+			case DedupDone:
+				return nil
+			case DedupInProgress:
+				return ErrDuplicateInProgress
+			}
+		} else if err := dd.Store.Mark(ctx, k, DedupInProgress, processingTTL); err != nil {
+			return fmt.Errorf("deduplicating message %s: %w", k, err)
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				dd.forget(k)
+				panic(r)
+			}
+		}()
+
+		if err := handler(ctx, d); err != nil {
+			dd.forget(k)
+			return err
+		}
+
+		if err := dd.Store.Mark(context.Background(), k, DedupDone, ttl); err != nil {
+			// The message is acknowledged anyway, as it was processed.
+			Logger.Printf("error recording message %s as processed: %+v", k, err)
+		}
+		return nil
+	}
+}
+
+func (dd *Deduplicator) forget(key string) {
+	if err := dd.Store.Forget(context.Background(), key); err != nil {
+		Logger.Printf("error forgetting message %s after it failed: %+v", key, err)
+	}
+}
+
+// DefaultDedupCapacity is the default capacity of a MemoryDedupStore.
+const DefaultDedupCapacity = 10000
+
+// MemoryDedupStore is a DedupStore keeping records in memory, evicting the
+// least recently used ones beyond its capacity.  It only deduplicates messages
+// processed by the same application instance.
+type MemoryDedupStore struct {
+	m        sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      list.List // of *dedupEntry, most recently used first
+	now      func() time.Time
+}
+
+type dedupEntry struct {
+	key     string
+	state   DedupState
+	expires time.Time
+}
+
+// NewMemoryDedupStore returns an empty MemoryDedupStore holding up to capacity
+// records, DefaultDedupCapacity when capacity is not positive.
+func NewMemoryDedupStore(capacity int) *MemoryDedupStore {
+	if capacity <= 0 {
+		capacity = DefaultDedupCapacity
+	}
+	return &MemoryDedupStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Begin implements DedupStore.
+func (s *MemoryDedupStore) Begin(_ context.Context, key string, ttl time.Duration) (DedupState, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if e := s.lookup(key); e != nil {
+		return e.state, nil
+	}
+	s.set(key, DedupInProgress, ttl)
+	return DedupNew, nil
+}
+
+// Mark implements DedupStore.
+func (s *MemoryDedupStore) Mark(_ context.Context, key string, state DedupState, ttl time.Duration) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.set(key, state, ttl)
+	return nil
+}
+
+// Forget implements DedupStore.
+func (s *MemoryDedupStore) Forget(_ context.Context, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// Len returns the number of records held, including expired ones not evicted
+// yet.
+func (s *MemoryDedupStore) Len() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.lru.Len()
+}
+
+// lookup returns the unexpired record of key, marking it as recently used.
+func (s *MemoryDedupStore) lookup(key string) *dedupEntry {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*dedupEntry)
+	if !s.now().Before(e.expires) {
+		s.remove(el)
+		return nil
+	}
+	s.lru.MoveToFront(el)
+	return e
+}
+
+func (s *MemoryDedupStore) set(key string, state DedupState, ttl time.Duration) {
+	expires := s.now().Add(ttl)
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*dedupEntry)
+		e.state, e.expires = state, expires
+		s.lru.MoveToFront(el)
+		return
+	}
+
+	s.entries[key] = s.lru.PushFront(&dedupEntry{key: key, state: state, expires: expires})
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+}
+
+func (s *MemoryDedupStore) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*dedupEntry).key)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeduplicatorHandlesMessagesOnce(t *testing.T) {
+	store := NewMemoryDedupStore(0)
+	dd := NewDeduplicator(store)
+
+	var calls []string
+	fail := errors.New("failed")
+	handler := dd.Wrap(func(ctx context.Context, d Delivery) error {
+		calls = append(calls, d.MessageId)
+		if string(d.Body) == "fail" {
+			return fail
+		}
+		return nil
+	})
+
+	first := Delivery{MessageId: "m-1"}
+	redelivered := Delivery{MessageId: "m-1", Redelivered: true}
+
+	if err := handler(context.TODO(), first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler(context.TODO(), redelivered); err != nil {
+		t.Fatalf("expected a processed redelivery to be acknowledged, got: %v", err)
+	}
+	// Without the hint, a message published twice is not looked up.
+	if err := handler(context.TODO(), first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	failed := Delivery{MessageId: "m-2", Body: []byte("fail")}
+	if err := handler(context.TODO(), failed); err != fail {
+		t.Fatalf("expected the handler error, got: %v", err)
+	}
+	failed.Redelivered = true
+	if err := handler(context.TODO(), failed); err != fail {
+		t.Fatalf("expected a failed message to be processed again, got: %v", err)
+	}
+
+	if err := handler(context.TODO(), Delivery{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"m-1", "m-1", "m-2", "m-2", ""}
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %q, got %q", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("expected calls %q, got %q", want, calls)
+		}
+	}
+
+	dd.CheckFirstDeliveries = true
+	checked := dd.Wrap(func(ctx context.Context, d Delivery) error {
+		t.Errorf("unexpected call for a processed message")
+		return nil
+	})
+	if err := checked(context.TODO(), first); err != nil {
+		t.Errorf("expected a message published twice to be acknowledged, got: %v", err)
+	}
+}
+
+func TestDeduplicatorGuardsMessagesInProgress(t *testing.T) {
+	dd := NewDeduplicator(NewMemoryDedupStore(0))
+	dd.InProgressDelay = 10 * time.Millisecond
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := dd.Wrap(func(ctx context.Context, d Delivery) error {
+		if d.Redelivered {
+			t.Errorf("unexpected call for a message in progress")
+			return nil
+		}
+		close(started)
+		<-release
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- handler(context.TODO(), Delivery{MessageId: "m"}) }()
+	<-started
+
+	start := time.Now()
+	if err := handler(context.TODO(), Delivery{MessageId: "m", Redelivered: true}); err != ErrDuplicateInProgress {
+		t.Errorf("expected ErrDuplicateInProgress, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < dd.InProgressDelay {
+		t.Errorf("expected the duplicate to be held for %v, returned after %v", dd.InProgressDelay, elapsed)
+	}
+
+	// A duplicate arriving while the message is processed is acknowledged
+	// once the processing ends within the delay.
+	dd.InProgressDelay = 100 * time.Millisecond
+	duplicated := dd.Wrap(func(ctx context.Context, d Delivery) error {
+		t.Errorf("unexpected call for a message in progress")
+		return nil
+	})
+	duplicate := make(chan error, 1)
+	go func() { duplicate <- duplicated(context.TODO(), Delivery{MessageId: "m", Redelivered: true}) }()
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-duplicate; err != nil {
+		t.Errorf("expected the duplicate to be acknowledged, got: %v", err)
+	}
+
+	// Cancelling the context stops waiting.
+	dd.InProgressDelay = time.Minute
+	slow := dd.Wrap(func(ctx context.Context, d Delivery) error {
+		t.Errorf("unexpected call for a message in progress")
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = dd.Store.Mark(context.TODO(), "c", DedupInProgress, time.Minute)
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- slow(ctx, Delivery{MessageId: "c", Redelivered: true}) }()
+	cancel()
+	if err := <-cancelled; err != ErrDuplicateInProgress {
+		t.Errorf("expected ErrDuplicateInProgress once the context is cancelled, got: %v", err)
+	}
+
+	// A panicking handler does not leave the message in progress.
+	panicking := dd.Wrap(func(ctx context.Context, d Delivery) error { panic("boom") })
+	func() {
+		defer func() { _ = recover() }()
+		_ = panicking(context.TODO(), Delivery{MessageId: "p"})
+	}()
+	if state, _ := dd.Store.Begin(context.TODO(), "p", time.Minute); state != DedupNew {
+		t.Errorf("expected the message to be forgotten after a panic, got state %d", state)
+	}
+}
+
+func TestMemoryDedupStoreEvictsAndExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryDedupStore(2)
+	s.now = func() time.Time { return now }
+	ctx := context.TODO()
+
+	_ = s.Mark(ctx, "a", DedupDone, time.Minute)
+	_ = s.Mark(ctx, "b", DedupDone, time.Hour)
+	if state, _ := s.Begin(ctx, "a", time.Minute); state != DedupDone {
+		t.Fatalf("expected a to be done, got %d", state)
+	}
+
+	// b is the least recently used.
+	_ = s.Mark(ctx, "c", DedupInProgress, time.Hour)
+	if s.Len() != 2 {
+		t.Errorf("expected the capacity to be kept, got %d records", s.Len())
+	}
+	if state, _ := s.Begin(ctx, "b", time.Minute); state != DedupNew {
+		t.Errorf("expected b to be evicted, got %d", state)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if state, _ := s.Begin(ctx, "b", time.Minute); state != DedupNew {
+		t.Errorf("expected b to have expired, got %d", state)
+	}
+	if state, _ := s.Begin(ctx, "c", time.Minute); state != DedupInProgress {
+		t.Errorf("expected c to be in progress, got %d", state)
+	}
+
+	_ = s.Forget(ctx, "c")
+	if state, _ := s.Begin(ctx, "c", time.Minute); state != DedupNew {
+		t.Errorf("expected c to be forgotten, got %d", state)
+	}
+}
+
+func TestConsumerRequeuesDuplicatesInProgress(t *testing.T) {
+	const tag = "idempotent"
+
+	nacks := make(chan *basicNack, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		deliver := &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Redelivered: true, Body: []byte("dup")}
+		deliver.Properties.MessageId = "m"
+		srv.send(1, deliver)
+
+		nack := &basicNack{}
+		srv.recv(1, nack)
+		nacks <- nack
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	store := NewMemoryDedupStore(0)
+	_ = store.Mark(context.TODO(), "m", DedupInProgress, time.Minute)
+
+	dd := NewDeduplicator(store)
+	dd.InProgressDelay = time.Millisecond
+	c := NewConsumer(ch, "work", dd.Wrap(func(ctx context.Context, d Delivery) error {
+		t.Errorf("unexpected call for a message in progress")
+		return nil
+	}))
+	c.Consumer = tag
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	// RequeueOnce would reject the redelivered message.
+	if nack := <-nacks; nack.DeliveryTag != 1 || !nack.Requeue {
+		t.Errorf("expected the duplicate to be requeued, got: %+v", nack)
+	}
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
@@ -90,15 +90,33 @@ type lifeCycle struct {
 	state     LifeCycleState   // The current state of the connection or channel.
 	listeners []*stateListener // The registered state change listeners.
 	mutex     *sync.Mutex      // The mutex to protect the state changes.
+	err       error            // The error of the last transition to StateClosed.
+	changed   chan struct{}    // Closed and replaced on every transition.
 }
 
 func newLifeCycle() *lifeCycle {
 	return &lifeCycle{
-		state: StateClosed,
-		mutex: &sync.Mutex{},
+		state:   StateClosed,
+		mutex:   &sync.Mutex{},
+		changed: make(chan struct{}),
 	}
 }
 
+// current returns the current state along with a channel that is closed on the
+// next transition, and the error of the last transition to StateClosed.
+func (l *lifeCycle) current() (LifeCycleState, <-chan struct{}, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state, l.changed, l.err
+}
+
+// broadcast wakes up the goroutines waiting on a transition.  This assumes the
+// caller holds the lifeCycle mutex.
+func (l *lifeCycle) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
 func (l *lifeCycle) State() LifeCycleState {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
@@ -114,6 +132,10 @@ func (l *lifeCycle) SetState(value LifeCycleState, err error) {
 
 	oldState := l.state
 	l.state = value
+	if value == StateClosed {
+		l.err = err
+	}
+	l.broadcast()
 
 	sc := &StateChanged{
 		From: oldState,
@@ -140,6 +162,7 @@ func (l *lifeCycle) setStateOpen(skippedTopologyEntities []TopologyRecoveryEntit
 	}
 	oldState := l.state
 	l.state = StateOpen
+	l.broadcast()
 	sc := &StateChanged{
 		From: oldState,
 		To:   StateOpen,
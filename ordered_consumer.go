@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import "hash/fnv"
+
+// DefaultLanePrefetch is the default number of deliveries prefetched per lane
+// of an ordered consumer, see NewOrderedConsumer.
+const DefaultLanePrefetch = 10
+
+// KeyFunc returns the key of a delivery whose order is preserved by an ordered
+// consumer, such as the identifier of the aggregate the message is about.
+type KeyFunc func(d Delivery) string
+
+// KeyByRoutingKey is a KeyFunc preserving the order of deliveries with the same
+// routing key.
+func KeyByRoutingKey(d Delivery) string {
+	return d.RoutingKey
+}
+
+// KeyByHeader returns a KeyFunc preserving the order of deliveries with the
+// same value for the header, which should be a string.  Deliveries without the
+// header share the empty key.
+func KeyByHeader(header string) KeyFunc {
+	return func(d Delivery) string {
+		key, _ := d.Headers[header].(string)
+		return key
+	}
+}
+
+/*
+NewOrderedConsumer returns a Consumer that handles deliveries from queue on ch
+in parallel while preserving their order per key.
+
+Each delivery is hashed by its key onto one of Concurrency lanes, each handled
+by a single goroutine in the order the deliveries arrived.  Deliveries with the
+same key are therefore handled one at a time and in order, while deliveries
+with different keys are handled in parallel unless they share a lane.  A
+delivery that is requeued after its handler failed is redelivered later, after
+deliveries with the same key that arrived in the meantime.
+
+Deliveries are acknowledged individually as their handlers return, in any
+order across lanes, so the consumer can share its channel.  Enable
+acknowledgement coalescing on the channel to save round trips, see
+Channel.EnableAckCoalescing.
+
+The channel's prefetch count is set to Prefetch, which bounds the deliveries
+waiting in all lanes: a slow lane holds back the others once the server has
+sent as many deliveries as the prefetch count allows.  Each lane buffers up to
+Prefetch deliveries so that handing a delivery to a busy lane never blocks the
+other lanes.
+*/
+func NewOrderedConsumer(ch *Channel, queue string, key KeyFunc, handler Handler) *Consumer {
+	c := NewConsumer(ch, queue, handler)
+	c.key = key
+	return c
+}
+
+// lanes returns the lanes of an ordered consumer, each buffering as many
+// deliveries as can be prefetched.
+func (c *Consumer) lanes(n, prefetch int) []chan Delivery {
+	lanes := make([]chan Delivery, n)
+	for i := range lanes {
+		lanes[i] = make(chan Delivery, prefetch)
+	}
+	return lanes
+}
+
+// lane returns the index of the lane, out of n, handling d.
+func (c *Consumer) lane(d Delivery, n int) int {
+	if n == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(c.key(d)))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrderedConsumerPreservesOrderPerKey(t *testing.T) {
+	const tag = "ordered"
+
+	// Pick a second key on the other lane than "a".
+	lanes := NewOrderedConsumer(nil, "", KeyByRoutingKey, nil)
+	other := ""
+	for i := 0; other == ""; i++ {
+		if key := fmt.Sprintf("b%d", i); lanes.lane(Delivery{RoutingKey: key}, 2) != lanes.lane(Delivery{RoutingKey: "a"}, 2) {
+			other = key
+		}
+	}
+	keys := []string{"a", other, "a", other, "a", other}
+
+	type served struct {
+		qos  *basicQos
+		acks []uint64
+	}
+	results := make(chan served, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r served
+
+		r.qos = &basicQos{}
+		srv.recv(1, r.qos)
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		for i, key := range keys {
+			deliver := &basicDeliver{ConsumerTag: tag, DeliveryTag: uint64(i + 1), Body: []byte(key)}
+			deliver.Properties.Headers = Table{"account": key}
+			srv.send(1, deliver)
+		}
+		for range keys {
+			ack := &basicAck{}
+			srv.recv(1, ack)
+			r.acks = append(r.acks, ack.DeliveryTag)
+		}
+		results <- r
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	var (
+		m       sync.Mutex
+		handled = make(map[string][]uint64)
+	)
+	otherHandled := make(chan struct{})
+	c := NewOrderedConsumer(ch, "work", KeyByHeader("account"), func(ctx context.Context, d Delivery) error {
+		key := string(d.Body)
+		if key == "a" && d.DeliveryTag == 1 {
+			// The other lane keeps going while this one is busy.
+			select {
+			case <-otherHandled:
+			case <-time.After(time.Second):
+				t.Error("expected the other key to be handled in parallel")
+			}
+		}
+
+		m.Lock()
+		handled[key] = append(handled[key], d.DeliveryTag)
+		if key == other && len(handled[key]) == 3 {
+			close(otherHandled)
+		}
+		m.Unlock()
+		return nil
+	})
+	c.Consumer = tag
+	c.Concurrency = 2
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("could not start consumer: %v", err)
+	}
+
+	r := <-results
+	if r.qos.PrefetchCount != 2*DefaultLanePrefetch {
+		t.Errorf("expected the default prefetch for 2 lanes, got %d", r.qos.PrefetchCount)
+	}
+	sort.Slice(r.acks, func(i, j int) bool { return r.acks[i] < r.acks[j] })
+	if fmt.Sprint(r.acks) != "[1 2 3 4 5 6]" {
+		t.Errorf("expected every delivery to be acknowledged, got %v", r.acks)
+	}
+
+	if err := c.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if got := fmt.Sprint(handled["a"]); got != "[1 3 5]" {
+		t.Errorf("expected key a to be handled in order, got %s", got)
+	}
+	if got := fmt.Sprint(handled[other]); got != "[2 4 6]" {
+		t.Errorf("expected key %s to be handled in order, got %s", other, got)
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	d := Delivery{RoutingKey: "orders.eu", Headers: Table{"account": "acme", "n": int32(1)}}
+
+	if got := KeyByRoutingKey(d); got != "orders.eu" {
+		t.Errorf("unexpected routing key: %q", got)
+	}
+	if got := KeyByHeader("account")(d); got != "acme" {
+		t.Errorf("unexpected header key: %q", got)
+	}
+	if got := KeyByHeader("n")(d); got != "" {
+		t.Errorf("expected a header that is not a string to give the empty key, got %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+/*
+Package outbox implements the transactional outbox pattern on top of
+database/sql and publisher confirms.
+
+A service writes its business data and the messages it wants to publish in the
+same SQL transaction, using Schema.Enqueue for the messages.  A Relay then
+reads the rows not yet sent from the outbox table, publishes them, waits for
+the broker to confirm them and marks only the confirmed rows as sent.
+
+Delivery is at least once: a row is marked as sent only after its publishing
+was confirmed, so a crash or a failure to mark rows after they were confirmed
+leads to the row being published again.  Each publishing's MessageId is
+derived from the table name and row id, so consumers can deduplicate.
+
+The expected table, with the default Schema, looks like:
+
+	CREATE TABLE outbox (
+		id           BIGINT PRIMARY KEY, -- increasing, e.g. auto-increment
+		exchange     VARCHAR(255) NOT NULL,
+		routing_key  VARCHAR(255) NOT NULL,
+		headers      BLOB,               -- AMQP field table, may be NULL
+		content_type VARCHAR(255),       -- may be NULL
+		body         BLOB NOT NULL,
+		sent_at      TIMESTAMP NULL      -- NULL until confirmed
+	)
+*/
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Schema names the outbox table and its columns.  The names are inserted in
+// queries as is, so they must come from trusted configuration.  Empty fields
+// take the defaults documented on each field.
+type Schema struct {
+	Table             string // "outbox"
+	IDColumn          string // "id", an integer increasing with insertion order
+	ExchangeColumn    string // "exchange"
+	RoutingKeyColumn  string // "routing_key"
+	HeadersColumn     string // "headers", an AMQP field table or NULL
+	ContentTypeColumn string // "content_type", may be NULL
+	BodyColumn        string // "body"
+	SentAtColumn      string // "sent_at", NULL until the row is confirmed
+
+	// Placeholder returns the query placeholder for the n-th argument,
+	// starting at 1.  It defaults to QuestionPlaceholder.
+	Placeholder func(n int) string
+
+	// LockClause is appended to the query selecting the rows to relay, for
+	// example "FOR UPDATE SKIP LOCKED" so that several relays can share the
+	// table.  The rows stay locked until they are marked as sent.
+	LockClause string
+}
+
+// QuestionPlaceholder is the "?" placeholder used by MySQL and SQLite.
+func QuestionPlaceholder(int) string { return "?" }
+
+// DollarPlaceholder is the "$n" placeholder used by PostgreSQL.
+func DollarPlaceholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (s Schema) withDefaults() Schema {
+	def := func(v *string, d string) {
+		if *v == "" {
+			*v = d
+		}
+	}
+	def(&s.Table, "outbox")
+	def(&s.IDColumn, "id")
+	def(&s.ExchangeColumn, "exchange")
+	def(&s.RoutingKeyColumn, "routing_key")
+	def(&s.HeadersColumn, "headers")
+	def(&s.ContentTypeColumn, "content_type")
+	def(&s.BodyColumn, "body")
+	def(&s.SentAtColumn, "sent_at")
+	if s.Placeholder == nil {
+		s.Placeholder = QuestionPlaceholder
+	}
+	return s
+}
+
+// Execer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+/*
+Enqueue inserts a message in the outbox table.  Call it with the *sql.Tx that
+holds the business write, so that the message is only relayed if the
+transaction commits.
+
+Only the message's Headers, ContentType and Body are stored.  Headers are
+stored with amqp.MarshalTable, so the relay publishes them with the same AMQP
+types.  The row id is left to the database, which must assign increasing ids.
+*/
+func (s Schema) Enqueue(ctx context.Context, db Execer, exchange, key string, msg amqp.Publishing) error {
+	var headers, contentType any
+	if len(msg.Headers) > 0 {
+		b, err := amqp.MarshalTable(msg.Headers)
+		if err != nil {
+			return fmt.Errorf("outbox: cannot encode headers: %w", err)
+		}
+		headers = b
+	}
+	if msg.ContentType != "" {
+		contentType = msg.ContentType
+	}
+
+	s = s.withDefaults()
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (%s, %s, %s, %s, %s)",
+		s.Table, s.ExchangeColumn, s.RoutingKeyColumn, s.HeadersColumn, s.ContentTypeColumn, s.BodyColumn,
+		s.Placeholder(1), s.Placeholder(2), s.Placeholder(3), s.Placeholder(4), s.Placeholder(5))
+
+	_, err := db.ExecContext(ctx, query, exchange, key, headers, contentType, msg.Body)
+	return err
+}
+
+func (s Schema) selectQuery(limit int) string {
+	query := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s FROM %s WHERE %s IS NULL ORDER BY %s LIMIT %d",
+		s.IDColumn, s.ExchangeColumn, s.RoutingKeyColumn, s.HeadersColumn, s.ContentTypeColumn, s.BodyColumn,
+		s.Table, s.SentAtColumn, s.IDColumn, limit)
+	if s.LockClause != "" {
+		query += " " + s.LockClause
+	}
+	return query
+}
+
+func (s Schema) markQuery(ids int) string {
+	in := make([]string, ids)
+	for i := range in {
+		in[i] = s.Placeholder(i + 2)
+	}
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s IN (%s)",
+		s.Table, s.SentAtColumn, s.Placeholder(1), s.IDColumn, strings.Join(in, ", "))
+}
+
+// Confirmation is the outcome of a publishing, as *amqp.DeferredConfirmation
+// provides it.
+type Confirmation interface {
+	// WaitContext blocks until the publishing is confirmed, returning
+	// whether the broker acknowledged it, or until ctx is done.
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// Publisher publishes the rows relayed by a Relay and returns their
+// confirmation.  NewChannelPublisher adapts an *amqp.Channel.
+type Publisher interface {
+	Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (Confirmation, error)
+}
+
+// ErrNoConfirmation is returned when the channel behind a Publisher is not in
+// confirm mode, so the outcome of a publishing cannot be known.
+var ErrNoConfirmation = errors.New("outbox: channel is not in confirm mode")
+
+type channelPublisher struct {
+	ch *amqp.Channel
+}
+
+// NewChannelPublisher puts ch in confirm mode and returns a Publisher
+// publishing on it.  The channel should be dedicated to the relay.
+func NewChannelPublisher(ch *amqp.Channel) (Publisher, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, err
+	}
+	return channelPublisher{ch: ch}, nil
+}
+
+func (p channelPublisher) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (Confirmation, error) {
+	dc, err := p.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return nil, err
+	}
+	if dc == nil {
+		return nil, ErrNoConfirmation
+	}
+	return dc, nil
+}
@@ -0,0 +1,421 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeStore is an in-memory outbox table behind the fake driver.  It only
+// understands the queries built by Schema.
+type fakeStore struct {
+	m          sync.Mutex
+	rows       []*fakeRow
+	queries    []string
+	markErr    error
+	committed  int
+	rolledBack int
+}
+
+type fakeRow struct {
+	id          int64
+	exchange    string
+	key         string
+	headers     driver.Value
+	contentType driver.Value
+	body        []byte
+	sentAt      driver.Value
+}
+
+func (s *fakeStore) sent(id int64) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, r := range s.rows {
+		if r.id == id {
+			return r.sentAt != nil
+		}
+	}
+	return false
+}
+
+var (
+	fakeStoresM sync.Mutex
+	fakeStores  = map[string]*fakeStore{}
+)
+
+func init() {
+	sql.Register("outboxfake", fakeDriver{})
+}
+
+// openFakeDB returns a database backed by a new fakeStore.
+func openFakeDB(t *testing.T) (*sql.DB, *fakeStore) {
+	t.Helper()
+
+	store := &fakeStore{}
+	fakeStoresM.Lock()
+	fakeStores[t.Name()] = store
+	fakeStoresM.Unlock()
+
+	db, err := sql.Open("outboxfake", t.Name())
+	if err != nil {
+		t.Fatalf("could not open fake database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, store
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeStoresM.Lock()
+	defer fakeStoresM.Unlock()
+	store, ok := fakeStores[name]
+	if !ok {
+		return nil, fmt.Errorf("no fake store %q", name)
+	}
+	return &fakeConn{store: store}, nil
+}
+
+type fakeConn struct {
+	store *fakeStore
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{store: c.store, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{store: c.store}, nil }
+
+type fakeTx struct {
+	store *fakeStore
+}
+
+func (tx fakeTx) Commit() error {
+	tx.store.m.Lock()
+	tx.store.committed++
+	tx.store.m.Unlock()
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.store.m.Lock()
+	tx.store.rolledBack++
+	tx.store.m.Unlock()
+	return nil
+}
+
+type fakeStmt struct {
+	store *fakeStore
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	st := s.store
+	st.m.Lock()
+	defer st.m.Unlock()
+	st.queries = append(st.queries, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		body, _ := args[4].([]byte)
+		st.rows = append(st.rows, &fakeRow{
+			id:          int64(len(st.rows) + 1),
+			exchange:    args[0].(string),
+			key:         args[1].(string),
+			headers:     args[2],
+			contentType: args[3],
+			body:        body,
+		})
+		return driver.RowsAffected(1), nil
+
+	case strings.HasPrefix(s.query, "UPDATE"):
+		if st.markErr != nil {
+			return nil, st.markErr
+		}
+		var n int64
+		for _, id := range args[1:] {
+			for _, r := range st.rows {
+				if r.id == id.(int64) {
+					r.sentAt = args[0]
+					n++
+				}
+			}
+		}
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("unexpected exec: %s", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	st := s.store
+	st.m.Lock()
+	defer st.m.Unlock()
+	st.queries = append(st.queries, s.query)
+
+	var limit int
+	if i := strings.Index(s.query, "LIMIT "); i < 0 || !strings.HasPrefix(s.query, "SELECT") {
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	} else if _, err := fmt.Sscanf(s.query[i:], "LIMIT %d", &limit); err != nil {
+		return nil, err
+	}
+
+	rows := &fakeRows{}
+	for _, r := range st.rows {
+		if r.sentAt == nil && len(rows.rows) < limit {
+			rows.rows = append(rows.rows, []driver.Value{r.id, r.exchange, r.key, r.headers, r.contentType, r.body})
+		}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "exchange", "routing_key", "headers", "content_type", "body"}
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeConfirmation struct {
+	acked bool
+}
+
+func (c fakeConfirmation) WaitContext(context.Context) (bool, error) {
+	return c.acked, nil
+}
+
+// fakePublisher records publishings and nacks those whose MessageId is in
+// nack.
+type fakePublisher struct {
+	m         sync.Mutex
+	published []amqp.Publishing
+	keys      []string
+	nack      map[string]bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (Confirmation, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.published = append(p.published, msg)
+	p.keys = append(p.keys, exchange+"/"+key)
+	return fakeConfirmation{acked: !p.nack[msg.MessageId]}, nil
+}
+
+func enqueue(t *testing.T, db *sql.DB, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		if err := (Schema{}).Enqueue(context.TODO(), db, "ex", fmt.Sprintf("key.%d", i), amqp.Publishing{Body: []byte{byte(i)}}); err != nil {
+			t.Fatalf("could not enqueue: %v", err)
+		}
+	}
+}
+
+func TestEnqueueAndRelay(t *testing.T) {
+	db, store := openFakeDB(t)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("could not begin: %v", err)
+	}
+	headers := amqp.Table{
+		"count":   int32(3),
+		"total":   int64(3),
+		"ratio":   2.0,
+		"id":      []byte("raw"),
+		"at":      time.Unix(1700000000, 0),
+		"price":   amqp.Decimal{Scale: 2, Value: 1999},
+		"nested":  amqp.Table{"ratio": 0.5},
+		"tags":    []any{"a", int8(1)},
+		"missing": nil,
+	}
+	msg := amqp.Publishing{
+		Headers:     headers,
+		ContentType: "application/json",
+		Body:        []byte(`{"order":1}`),
+	}
+	if err := (Schema{}).Enqueue(context.TODO(), tx, "orders", "order.created", msg); err != nil {
+		t.Fatalf("could not enqueue: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("could not commit: %v", err)
+	}
+
+	publisher := &fakePublisher{}
+	relay := NewRelay(db, publisher, Config{})
+	if relay.config.ConfirmTimeout != DefaultConfirmTimeout {
+		t.Errorf("expected the confirmations to be waited for %v by default, got: %v", DefaultConfirmTimeout, relay.config.ConfirmTimeout)
+	}
+
+	res, err := relay.RelayBatch(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+	if len(res.Confirmed) != 1 || res.Confirmed[0] != 1 || len(res.Unconfirmed) != 0 {
+		t.Fatalf("expected row 1 to be confirmed, got: %+v", res)
+	}
+	if !store.sent(1) {
+		t.Error("expected row 1 to be marked as sent")
+	}
+
+	got := publisher.published[0]
+	if publisher.keys[0] != "orders/order.created" {
+		t.Errorf("unexpected exchange and routing key: %s", publisher.keys[0])
+	}
+	if got.MessageId != "outbox-1" || got.DeliveryMode != amqp.Persistent || got.ContentType != "application/json" || string(got.Body) != `{"order":1}` {
+		t.Errorf("unexpected publishing: %+v", got)
+	}
+	if err := got.Headers.Validate(); err != nil {
+		t.Errorf("expected valid headers, got: %v", err)
+	}
+	if !reflect.DeepEqual(got.Headers, headers) {
+		t.Errorf("expected the headers to keep their types, got: %#v", got.Headers)
+	}
+
+	res, err = relay.RelayBatch(context.TODO())
+	if err != nil || len(res.Confirmed) != 0 || len(res.Unconfirmed) != 0 {
+		t.Errorf("expected nothing left to relay, got: %+v, %v", res, err)
+	}
+}
+
+func TestRelayStrictStopsAtFirstUnconfirmedRow(t *testing.T) {
+	db, store := openFakeDB(t)
+	enqueue(t, db, 3)
+
+	publisher := &fakePublisher{nack: map[string]bool{"outbox-2": true}}
+	res, err := NewRelay(db, publisher, Config{}).RelayBatch(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+
+	if fmt.Sprint(res.Confirmed) != "[1]" || fmt.Sprint(res.Unconfirmed) != "[2 3]" {
+		t.Errorf("expected row 1 confirmed and rows 2 and 3 left, got: %+v", res)
+	}
+	if !errors.Is(res.Errors[2], ErrNacked) {
+		t.Errorf("expected row 2 to be nacked, got: %v", res.Errors[2])
+	}
+	if _, found := res.Errors[3]; found {
+		t.Error("expected no error for row 3, which was not attempted")
+	}
+	if len(publisher.published) != 2 {
+		t.Errorf("expected publishing to stop after row 2, got %d publishings", len(publisher.published))
+	}
+	if !store.sent(1) || store.sent(2) || store.sent(3) {
+		t.Error("expected only row 1 to be marked as sent")
+	}
+}
+
+func TestRelayPipelined(t *testing.T) {
+	db, store := openFakeDB(t)
+	enqueue(t, db, 3)
+
+	publisher := &fakePublisher{nack: map[string]bool{"outbox-2": true}}
+	res, err := NewRelay(db, publisher, Config{Ordering: OrderingPipelined, BatchSize: 2}).RelayBatch(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected relay error: %v", err)
+	}
+
+	if fmt.Sprint(res.Confirmed) != "[1]" || fmt.Sprint(res.Unconfirmed) != "[2]" {
+		t.Errorf("expected a batch of 2 with row 1 confirmed, got: %+v", res)
+	}
+	if !store.sent(1) || store.sent(2) || store.sent(3) {
+		t.Error("expected only row 1 to be marked as sent")
+	}
+
+	publisher.nack = nil
+	res, err = NewRelay(db, publisher, Config{Ordering: OrderingPipelined}).RelayBatch(context.TODO())
+	if err != nil || fmt.Sprint(res.Confirmed) != "[2 3]" {
+		t.Errorf("expected rows 2 and 3 to be confirmed, got: %+v, %v", res, err)
+	}
+}
+
+func TestRelayMarkError(t *testing.T) {
+	db, store := openFakeDB(t)
+	enqueue(t, db, 2)
+	store.markErr = errors.New("disk full")
+
+	res, err := NewRelay(db, &fakePublisher{}, Config{}).RelayBatch(context.TODO())
+
+	var markErr *MarkError
+	if !errors.As(err, &markErr) {
+		t.Fatalf("expected a MarkError, got: %v", err)
+	}
+	if fmt.Sprint(markErr.Confirmed) != "[1 2]" {
+		t.Errorf("expected the error to list the confirmed rows, got: %v", markErr.Confirmed)
+	}
+	if len(res.Confirmed) != 0 {
+		t.Errorf("expected no row reported as marked, got: %v", res.Confirmed)
+	}
+	if store.sent(1) || store.sent(2) || store.committed != 0 {
+		t.Error("expected the rows to be left unsent")
+	}
+}
+
+func TestRelayRun(t *testing.T) {
+	db, store := openFakeDB(t)
+	enqueue(t, db, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewRelay(db, &fakePublisher{}, Config{BatchSize: 2, PollInterval: time.Millisecond}).Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !store.sent(5) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected all rows to be relayed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Run to return the context error, got: %v", err)
+	}
+}
+
+func TestSchemaQueries(t *testing.T) {
+	s := Schema{
+		Table:        "events",
+		IDColumn:     "seq",
+		SentAtColumn: "published_at",
+		Placeholder:  DollarPlaceholder,
+		LockClause:   "FOR UPDATE SKIP LOCKED",
+	}.withDefaults()
+
+	if got, want := s.selectQuery(10), "SELECT seq, exchange, routing_key, headers, content_type, body FROM events WHERE published_at IS NULL ORDER BY seq LIMIT 10 FOR UPDATE SKIP LOCKED"; got != want {
+		t.Errorf("unexpected select query:\n got: %s\nwant: %s", got, want)
+	}
+	if got, want := s.markQuery(2), "UPDATE events SET published_at = $1 WHERE seq IN ($2, $3)"; got != want {
+		t.Errorf("unexpected mark query:\n got: %s\nwant: %s", got, want)
+	}
+}
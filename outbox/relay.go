@@ -0,0 +1,323 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Ordering selects how a Relay publishes the rows of a batch.
+type Ordering int
+
+const (
+	// OrderingStrict publishes the rows of a batch one at a time, waiting
+	// for each confirmation, and stops at the first row that is not
+	// confirmed.  Rows are confirmed in id order, although a row may be
+	// published again after a later one when its confirmation is lost.
+	OrderingStrict Ordering = iota
+
+	// OrderingPipelined publishes all the rows of a batch before waiting for
+	// their confirmations.  It is much faster, but a row that is not
+	// confirmed is published again in a later batch, after rows that
+	// followed it.
+	OrderingPipelined
+)
+
+// Default Config values.
+const (
+	DefaultBatchSize      = 100
+	DefaultPollInterval   = time.Second
+	DefaultConfirmTimeout = 30 * time.Second
+)
+
+// Config configures a Relay.
+type Config struct {
+	Schema Schema
+
+	// BatchSize is the maximum number of rows relayed per batch.  It
+	// defaults to DefaultBatchSize.
+	BatchSize int
+
+	// PollInterval is how long Run waits before reading the table again
+	// after a batch that was not full.  It defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	Ordering Ordering
+
+	// ConfirmTimeout bounds the wait for the confirmations of a batch, during
+	// which the batch's transaction holds its rows locked.  Rows not
+	// confirmed in time are left unsent.  It defaults to
+	// DefaultConfirmTimeout, a negative value means no timeout.
+	ConfirmTimeout time.Duration
+
+	// DeliveryMode of the publishings.  It defaults to amqp.Persistent.
+	DeliveryMode uint8
+}
+
+// Result reports the outcome of relaying a batch.  Every row read is in
+// either Confirmed or Unconfirmed.
+type Result struct {
+	// Confirmed holds the ids of the rows confirmed by the broker and marked
+	// as sent, in id order.
+	Confirmed []int64
+
+	// Unconfirmed holds the ids of the rows left unsent, in id order.  They
+	// may or may not have reached the broker, and are relayed again.
+	Unconfirmed []int64
+
+	// Errors holds why each unconfirmed row was not confirmed.  Rows that
+	// were not attempted, after an earlier failure with OrderingStrict, have
+	// no entry.
+	Errors map[int64]error
+}
+
+// MarkError is returned by Relay.RelayBatch when rows were confirmed by the
+// broker but could not be marked as sent.  They are published again by a later
+// batch.
+type MarkError struct {
+	Confirmed []int64
+	Err       error
+}
+
+func (e *MarkError) Error() string {
+	return fmt.Sprintf("outbox: %d confirmed rows could not be marked as sent: %v", len(e.Confirmed), e.Err)
+}
+
+func (e *MarkError) Unwrap() error {
+	return e.Err
+}
+
+// Relay publishes the rows of an outbox table and marks them as sent once
+// they are confirmed.  A Relay must not be used from several goroutines at
+// once; run several relays with a Schema.LockClause to share the work.
+type Relay struct {
+	db        *sql.DB
+	publisher Publisher
+	config    Config
+}
+
+// NewRelay returns a Relay reading rows from db and publishing them with
+// publisher.
+func NewRelay(db *sql.DB, publisher Publisher, config Config) *Relay {
+	config.Schema = config.Schema.withDefaults()
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.ConfirmTimeout == 0 {
+		config.ConfirmTimeout = DefaultConfirmTimeout
+	}
+	if config.DeliveryMode == 0 {
+		config.DeliveryMode = amqp.Persistent
+	}
+	return &Relay{db: db, publisher: publisher, config: config}
+}
+
+// Run relays batches until ctx is done, which it returns.  A batch that fails
+// is logged with amqp.Logger and retried after the poll interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		res, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			amqp.Logger.Printf("outbox relay batch failed: %+v", err)
+		}
+
+		// Go on straight away while there is a backlog.
+		if err == nil && len(res.Unconfirmed) == 0 && len(res.Confirmed) == r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+type row struct {
+	id       int64
+	exchange string
+	key      string
+	msg      amqp.Publishing
+}
+
+/*
+RelayBatch relays a single batch of at most BatchSize unsent rows in id order,
+in a transaction that is committed once the confirmed rows are marked as sent.
+
+The returned Result tells which rows were confirmed and marked, and which were
+left unsent.  When rows were confirmed but marking them failed, a *MarkError
+listing them is returned and Result.Confirmed is empty.
+*/
+func (r *Relay) RelayBatch(ctx context.Context) (Result, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer func() {
+		// No-op once committed.
+		_ = tx.Rollback()
+	}()
+
+	rows, err := r.read(ctx, tx)
+	if err != nil || len(rows) == 0 {
+		return Result{}, err
+	}
+
+	var confirmed []int64
+	res := Result{Errors: make(map[int64]error)}
+
+	switch r.config.Ordering {
+	case OrderingPipelined:
+		confirmed = r.publishPipelined(ctx, rows, res.Errors)
+	default:
+		confirmed = r.publishStrict(ctx, rows, res.Errors)
+	}
+
+	isConfirmed := make(map[int64]bool, len(confirmed))
+	for _, id := range confirmed {
+		isConfirmed[id] = true
+	}
+	for _, row := range rows {
+		if !isConfirmed[row.id] {
+			res.Unconfirmed = append(res.Unconfirmed, row.id)
+		}
+	}
+
+	if len(confirmed) == 0 {
+		return res, nil
+	}
+
+	args := make([]any, 0, len(confirmed)+1)
+	args = append(args, time.Now().UTC())
+	for _, id := range confirmed {
+		args = append(args, id)
+	}
+
+	// Marking must not be abandoned because ctx is done: the rows are
+	// already confirmed.
+	if _, err := tx.ExecContext(context.Background(), r.config.Schema.markQuery(len(confirmed)), args...); err != nil {
+		return res, &MarkError{Confirmed: confirmed, Err: err}
+	}
+	if err := tx.Commit(); err != nil {
+		return res, &MarkError{Confirmed: confirmed, Err: err}
+	}
+
+	res.Confirmed = confirmed
+	return res, nil
+}
+
+func (r *Relay) read(ctx context.Context, tx *sql.Tx) ([]row, error) {
+	rs, err := tx.QueryContext(ctx, r.config.Schema.selectQuery(r.config.BatchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	var rows []row
+	for rs.Next() {
+		var (
+			row         row
+			headers     []byte
+			contentType sql.NullString
+		)
+		if err := rs.Scan(&row.id, &row.exchange, &row.key, &headers, &contentType, &row.msg.Body); err != nil {
+			return nil, err
+		}
+		if len(headers) > 0 {
+			if row.msg.Headers, err = amqp.UnmarshalTable(headers); err != nil {
+				return nil, fmt.Errorf("outbox: row %d: cannot decode headers: %w", row.id, err)
+			}
+		}
+		row.msg.ContentType = contentType.String
+		row.msg.DeliveryMode = r.config.DeliveryMode
+		row.msg.MessageId = fmt.Sprintf("%s-%d", r.config.Schema.Table, row.id)
+		rows = append(rows, row)
+	}
+	return rows, rs.Err()
+}
+
+func (r *Relay) confirmContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.config.ConfirmTimeout > 0 {
+		return context.WithTimeout(ctx, r.config.ConfirmTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// publishStrict publishes rows one at a time, returning the ids confirmed
+// before the first failure.
+func (r *Relay) publishStrict(ctx context.Context, rows []row, errs map[int64]error) (confirmed []int64) {
+	ctx, cancel := r.confirmContext(ctx)
+	defer cancel()
+
+	for _, row := range rows {
+		if err := r.publish(ctx, row); err != nil {
+			errs[row.id] = err
+			return confirmed
+		}
+		confirmed = append(confirmed, row.id)
+	}
+	return confirmed
+}
+
+// publishPipelined publishes all rows and then waits for their
+// confirmations, returning the ids confirmed.
+func (r *Relay) publishPipelined(ctx context.Context, rows []row, errs map[int64]error) (confirmed []int64) {
+	ctx, cancel := r.confirmContext(ctx)
+	defer cancel()
+
+	confirmations := make([]Confirmation, len(rows))
+	for i, row := range rows {
+		c, err := r.publisher.Publish(ctx, row.exchange, row.key, row.msg)
+		if err != nil {
+			errs[row.id] = err
+			continue
+		}
+		confirmations[i] = c
+	}
+
+	for i, row := range rows {
+		if confirmations[i] == nil {
+			continue
+		}
+		if err := wait(ctx, confirmations[i]); err != nil {
+			errs[row.id] = err
+			continue
+		}
+		confirmed = append(confirmed, row.id)
+	}
+	return confirmed
+}
+
+func (r *Relay) publish(ctx context.Context, row row) error {
+	c, err := r.publisher.Publish(ctx, row.exchange, row.key, row.msg)
+	if err != nil {
+		return err
+	}
+	return wait(ctx, c)
+}
+
+// ErrNacked is recorded in Result.Errors for rows negatively acknowledged by
+// the broker.
+var ErrNacked = errors.New("outbox: publishing negatively acknowledged by the broker")
+
+func wait(ctx context.Context, c Confirmation) error {
+	acked, err := c.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return ErrNacked
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// streamChunkSize bounds the body frames written by PublishStream when no
+// frame size limit was negotiated with the server.
+const streamChunkSize = 128 * 1024
+
+/*
+PublishStream sends a Publishing whose body is read from body instead of
+msg.Body, which is ignored.  size is the exact number of bytes the body
+contains; it is announced to the server in the content header before any of
+the body is read, so it must be known up front.
+
+Body frames are written straight from the reader in chunks no larger than the
+negotiated FrameSize, so the body never has to be held in memory as a whole.
+The channel is held for the duration of the call: other publishings and
+acknowledgements on the same channel wait until the stream completes, so their
+frames never interleave with the streamed content.
+
+Important: body is read while the channel is held, so every Ack, Publish and
+RPC on the channel waits for as long as body takes to return, and neither
+Channel.Close nor ctx can interrupt a blocked read.  Use a reader that returns
+in bounded time, such as a file or an in-memory buffer, rather than a network
+connection, or publish from a dedicated channel.  ctx is checked before and
+after each read.
+
+The first chunk is read before anything is sent.  If body fails or ends early
+at that point, or ctx is already done, the error is returned and the channel
+is left untouched.  Once the content header has been sent the server expects
+exactly size bytes, and AMQP provides no way to abandon a partially sent
+message.  If body fails or ends early after that point, or ctx is cancelled
+while the body is read, the channel is closed and the wrapped error is
+returned.  The server may treat the truncated content as a protocol error and
+close the connection.
+
+When Recovery.WaitForRecovery is set, a publishing that fails because the
+channel is being recovered is sent again once the channel is open, as long as
+no more than the first chunk of body has been read.
+
+When the channel is in confirm mode the returned DeferredConfirmation can be
+used to wait for the publisher confirmation, as with PublishWithDeferredConfirm.
+*/
+func (ch *Channel) PublishStream(ctx context.Context, exchange, key string, msg Publishing, size int64, body io.Reader) (*DeferredConfirmation, error) {
+	if err := msg.Headers.Validate(); err != nil {
+		return nil, err
+	}
+
+	if size < 0 {
+		return nil, fmt.Errorf("amqp: invalid publish stream size %d", size)
+	}
+
+	if max := ch.connection.Config.MaxMessageSize; max > 0 && size > int64(max) {
+		return nil, ErrMessageTooLarge
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	chunk := int64(streamChunkSize)
+	if fs := ch.connection.Config.FrameSize; fs > 0 {
+		chunk = int64(fs - frameHeaderSize)
+	}
+	if chunk > size {
+		chunk = size
+	}
+
+	buf := make([]byte, chunk)
+
+	// Read ahead the first chunk so readers that fail straight away don't
+	// leave a partial message behind.
+	n, err := io.ReadFull(body, buf)
+	if err != nil {
+		return nil, streamReadError(0, size, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		dc          *DeferredConfirmation
+		aborted     bool
+		incarnation uint64
+		streamErr   error
+	)
+	rest := &trackedReader{r: body}
+	err = ch.awaitRecovery(ctx, func() (err error) {
+		if err := ch.awaitFlow(ctx, 0); err != nil {
+			return err
+		}
+		dc, aborted, incarnation, err = ch.sendStream(ctx, exchange, key, msg, size, buf, n, rest)
+		if rest.read {
+			// Only the first chunk is kept, so the publishing cannot be
+			// sent again once more of the body has been read.
+			streamErr = err
+			return nil
+		}
+		return err
+	})
+	if streamErr != nil {
+		err = streamErr
+	}
+
+	// The server is still waiting for the rest of the content and no other
+	// frame can be sent on this channel until it arrives, so the channel
+	// cannot be used any further.  A channel that was closed or recovered
+	// meanwhile no longer has the partial content, and closing it would
+	// abort its recovery.
+	if aborted && !ch.IsClosed() && ch.incarnation.Load() == incarnation {
+		_ = ch.Close()
+	}
+
+	return dc, err
+}
+
+// sendStream writes the method, header and body frames of a streamed
+// publishing while holding the channel, starting with the first n bytes
+// already read into buf.  aborted reports that the content was left incomplete
+// after the header frame had been sent on the channel's incarnation.
+func (ch *Channel) sendStream(ctx context.Context, exchange, key string, msg Publishing, size int64, buf []byte, n int, body io.Reader) (dc *DeferredConfirmation, aborted bool, incarnation uint64, err error) {
+	chunk := int64(len(buf))
+
+	ch.m.Lock()
+	defer ch.m.Unlock()
+
+	if ch.IsClosed() {
+		return nil, false, 0, ErrClosed
+	}
+	incarnation = ch.incarnation.Load()
+
+	if ch.confirming.Load() {
+		dc = ch.confirms.publish()
+	}
+
+	defer func() {
+		if err != nil && ch.confirming.Load() {
+			ch.confirms.unpublish()
+			dc = nil
+		}
+	}()
+
+	// Flush the buffer once all the frames have been written, as in sendOpen.
+	defer func() {
+		if endError := ch.connection.endSendUnflushed(); endError != nil && err == nil {
+			err = endError
+		}
+	}()
+
+	class, _ := (&basicPublish{}).id()
+
+	if err = ch.connection.sendUnflushed(&methodFrame{
+		ChannelId: ch.id,
+		Method: &basicPublish{
+			Exchange:   exchange,
+			RoutingKey: key,
+		},
+	}); err != nil {
+		return
+	}
+
+	if err = ch.connection.sendUnflushed(&headerFrame{
+		ChannelId:  ch.id,
+		ClassId:    class,
+		Size:       uint64(size),
+		Properties: msg.properties(),
+	}); err != nil {
+		return
+	}
+
+	var sent int64
+	for {
+		if n > 0 {
+			if err = ch.connection.sendUnflushed(&bodyFrame{
+				ChannelId: ch.id,
+				Body:      buf[:n],
+			}); err != nil {
+				return
+			}
+			sent += int64(n)
+		}
+
+		if sent == size {
+			return
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, true, incarnation, fmt.Errorf("amqp: publish stream aborted after %d of %d bytes: %w", sent, size, ctxErr)
+		}
+
+		next := size - sent
+		if next > chunk {
+			next = chunk
+		}
+
+		if n, err = io.ReadFull(body, buf[:next]); err != nil {
+			return nil, true, incarnation, streamReadError(sent, size, err)
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, true, incarnation, fmt.Errorf("amqp: publish stream aborted after %d of %d bytes: %w", sent, size, ctxErr)
+		}
+	}
+}
+
+func streamReadError(sent, size int64, err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("amqp: publish stream aborted after %d of %d bytes: %w", sent, size, err)
+}
+
+// trackedReader records whether it has been read from.
+type trackedReader struct {
+	r    io.Reader
+	read bool
+}
+
+func (t *trackedReader) Read(p []byte) (int, error) {
+	t.read = true
+	return t.r.Read(p)
+}
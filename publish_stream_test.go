@@ -0,0 +1,192 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, f.err
+	}
+	return n, err
+}
+
+func TestPublishStreamChunksBodyByFrameSize(t *testing.T) {
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	body := bytes.Repeat([]byte("0123456789"), 5000)
+	frames := make(chan []int, 1)
+	received := make(chan []byte, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		var sizes []int
+		var got []byte
+		for len(got) < len(body) {
+			f, err := srv.r.ReadFrame()
+			if err != nil {
+				t.Errorf("frame err, read: %s", err)
+				return
+			}
+			if b, ok := f.(*bodyFrame); ok {
+				sizes = append(sizes, len(b.Body))
+				got = append(got, b.Body...)
+			}
+		}
+		frames <- sizes
+		received <- got
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	if _, err := ch.PublishStream(context.TODO(), "", "q", Publishing{}, int64(len(body)), bytes.NewReader(body)); err != nil {
+		t.Fatalf("publish stream error: %v", err)
+	}
+
+	max := c.Config.FrameSize - frameHeaderSize
+	sizes := <-frames
+	if len(sizes) < 2 {
+		t.Fatalf("expected the body to span several frames, got %v", sizes)
+	}
+	for _, size := range sizes {
+		if size > max {
+			t.Errorf("body frame of %d bytes exceeds the frame size limit of %d", size, max)
+		}
+	}
+
+	if got := <-received; !bytes.Equal(got, body) {
+		t.Error("expected the streamed body to be received unchanged")
+	}
+}
+
+func TestPublishStreamFailingBeforeSendLeavesChannelOpen(t *testing.T) {
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	done := make(chan *basicPublish, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		done <- pub
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	readErr := errors.New("disk on fire")
+	_, err = ch.PublishStream(context.TODO(), "", "q", Publishing{}, 10, &failingReader{r: bytes.NewReader(nil), err: readErr})
+	if !errors.Is(err, readErr) {
+		t.Fatalf("expected the reader error, got: %v", err)
+	}
+
+	if ch.IsClosed() {
+		t.Fatal("expected channel to stay open when nothing was sent")
+	}
+
+	if _, err := ch.PublishStream(context.TODO(), "", "q", Publishing{}, 3, bytes.NewReader([]byte("abc"))); err != nil {
+		t.Fatalf("publish stream error: %v", err)
+	}
+
+	if pub := <-done; string(pub.Body) != "abc" {
+		t.Errorf("expected body %q, got %q", "abc", pub.Body)
+	}
+}
+
+// cancellingReader cancels a context while reading the end of its body.
+type cancellingReader struct {
+	r      *bytes.Reader
+	cancel context.CancelFunc
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if c.r.Len() == 0 {
+		c.cancel()
+	}
+	return n, err
+}
+
+// openStreamAbortChannel opens a channel whose server side drops every frame
+// until the channel is closed.
+func openStreamAbortChannel(t *testing.T) *Channel {
+	return openServedChannel(t, defaultConfig(), func(srv *server) {
+		for {
+			f, err := srv.r.ReadFrame()
+			if err != nil {
+				t.Errorf("frame err, read: %s", err)
+				return
+			}
+			if m, ok := f.(*methodFrame); ok {
+				if _, ok := m.Method.(*channelClose); ok {
+					break
+				}
+			}
+		}
+		srv.send(1, &channelCloseOk{})
+	})
+}
+
+func TestPublishStreamAbortClosesChannel(t *testing.T) {
+	ch := openStreamAbortChannel(t)
+
+	readErr := errors.New("disk on fire")
+	partial := &failingReader{r: bytes.NewReader(make([]byte, 30000)), err: readErr}
+	if _, err := ch.PublishStream(context.TODO(), "", "q", Publishing{}, 60000, partial); !errors.Is(err, readErr) {
+		t.Fatalf("expected the reader error, got: %v", err)
+	}
+
+	if !ch.IsClosed() {
+		t.Fatal("expected channel to be closed after aborting a partially sent body")
+	}
+}
+
+func TestPublishStreamChecksContextAfterEachRead(t *testing.T) {
+	ch := openStreamAbortChannel(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := &cancellingReader{r: bytes.NewReader(make([]byte, 60000)), cancel: cancel}
+	if _, err := ch.PublishStream(ctx, "", "q", Publishing{}, 60000, body); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got: %v", err)
+	}
+
+	if !ch.IsClosed() {
+		t.Fatal("expected channel to be closed after aborting a partially sent body")
+	}
+}
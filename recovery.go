@@ -186,6 +186,15 @@ type Recovery struct {
 	// Setting it to TopologyRecoveryDisabled disables topology and consumer recovery entirely.
 	TopologyRecoveryMode TopologyRecoveryMode
 
+	// WaitForRecovery makes Channel.PublishWithContext,
+	// Channel.PublishWithDeferredConfirmWithContext, Channel.PublishStream,
+	// Channel.ConsumeWithContext and RPCClient.Call wait for a channel that is
+	// being recovered to reach StateOpen again, instead of failing straight
+	// away with ErrClosed.  The wait is bounded by the context passed to the
+	// call.  They only fail with the terminal error once the channel or its
+	// connection reaches StateClosed.
+	WaitForRecovery bool
+
 	// OnTopologyEntityError is called each time a single topology entity fails to
 	// recover. Return true to skip the entity and continue recovering the remaining
 	// entities. Return false to abort topology recovery and trigger the normal retry
@@ -0,0 +1,229 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConsumerNotFound is returned by Channel.SetResubscribePolicy for a
+// consumer tag that is not consuming on the channel.
+var ErrConsumerNotFound = errors.New("amqp: consumer not found")
+
+// DefaultResubscribeMaxDelay is the default ResubscribePolicy.MaxDelay.
+const DefaultResubscribeMaxDelay = 30 * time.Second
+
+// ResubscribePolicy tells how a consumer cancelled by the server is subscribed
+// again.  The delay before each attempt starts at InitialDelay and doubles
+// after each failed attempt, up to MaxDelay.
+type ResubscribePolicy struct {
+	// MaxAttempts is the number of attempts before giving up, 0 for no
+	// limit.
+	MaxAttempts int
+
+	// InitialDelay defaults to DefaultResubscribeDelay.
+	InitialDelay time.Duration
+
+	// MaxDelay defaults to DefaultResubscribeMaxDelay.
+	MaxDelay time.Duration
+}
+
+// ResubscribeEvent reports an attempt to subscribe a consumer again after the
+// server cancelled it.  Use NotifyResubscribe on the Channel to receive them.
+type ResubscribeEvent struct {
+	Consumer string
+	Queue    string
+	Attempt  int   // 1 based
+	Err      error // nil when the consumer was subscribed again
+
+	// GaveUp is true when the attempt was the last one: the consumer's
+	// deliveries are closed as if no policy was set.
+	GaveUp bool
+}
+
+/*
+SetResubscribePolicy opts a consumer of the channel in to being subscribed again
+when the server cancels it, for example because its queue was deleted and
+declared again, or because the leader of its quorum queue moved.
+
+Instead of closing the consumer's deliveries, the channel checks that the queue
+exists by passively declaring it on a short-lived channel, so that the server
+closing that channel when the queue is missing does not affect this one.  It
+then subscribes again with the same consumer tag and arguments.  Attempts
+follow the policy until one succeeds, and deliveries keep arriving on the chan
+returned by Channel.Consume.  A nil policy opts the consumer out.
+
+Attempts stop when the channel closes or is recovered, as when subscribing
+fails: the consumer is then subscribed again by channel recovery, when enabled.  Cancelling the
+consumer with Channel.Cancel also stops them.  NotifyCancel listeners are still
+notified of the server's cancellation, and NotifyResubscribe listeners of every
+attempt.
+*/
+func (ch *Channel) SetResubscribePolicy(consumer string, policy *ResubscribePolicy) error {
+	if policy != nil {
+		p := *policy
+		if p.InitialDelay <= 0 {
+			p.InitialDelay = DefaultResubscribeDelay
+		}
+		if p.MaxDelay <= 0 {
+			p.MaxDelay = DefaultResubscribeMaxDelay
+		}
+		policy = &p
+	}
+
+	ch.consumers.Lock()
+	defer ch.consumers.Unlock()
+
+	config, ok := ch.consumers.configs[consumer]
+	if !ok {
+		return ErrConsumerNotFound
+	}
+	config.Resubscribe = policy
+	ch.consumers.configs[consumer] = config
+	return nil
+}
+
+/*
+NotifyResubscribe registers a listener for the attempts to subscribe consumers
+again after the server cancelled them, see Channel.SetResubscribePolicy.
+
+The listener is closed when the channel is closed.  It should be buffered or
+consumed continuously, as events are dropped after a timeout when it is full.
+*/
+func (ch *Channel) NotifyResubscribe(c chan ResubscribeEvent) chan ResubscribeEvent {
+	ch.notifyM.Lock()
+	defer ch.notifyM.Unlock()
+
+	if ch.noNotify {
+		close(c)
+	} else {
+		ch.resubscribes = append(ch.resubscribes, c)
+	}
+
+	return c
+}
+
+// resubscribe subscribes the consumer with tag again, following policy, until
+// it succeeds, gives up, the consumer is cancelled or the channel closes.  It
+// also stops once the channel was recovered since incarnation, as recovery
+// subscribes the consumer again itself, and the server closes the connection
+// when a consumer tag is reused.
+func (ch *Channel) resubscribe(tag string, policy ResubscribePolicy, incarnation uint64) {
+	delay := policy.InitialDelay
+
+	for attempt := 1; ; attempt++ {
+		if ch.incarnation.Load() != incarnation {
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-ch.consumers.closed:
+			return
+		}
+		if ch.incarnation.Load() != incarnation {
+			return
+		}
+
+		config, ok := ch.consumers.configFor(tag)
+		if !ok {
+			// Cancelled by the application meanwhile.
+			return
+		}
+
+		err := ch.subscribeAgain(tag, config)
+		event := ResubscribeEvent{Consumer: tag, Queue: config.Queue, Attempt: attempt, Err: err}
+		if err == nil {
+			if _, ok := ch.consumers.configFor(tag); !ok {
+				// Cancelled by the application while subscribing, its
+				// basic.cancel may have reached the server first.
+				_ = ch.Cancel(tag, false)
+			}
+			ch.notifyResubscribe(event)
+			return
+		}
+
+		if ch.IsClosed() {
+			ch.notifyResubscribe(event)
+			return
+		}
+
+		event.GaveUp = policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts
+		if event.GaveUp {
+			ch.consumers.cancel(tag)
+		}
+		ch.notifyResubscribe(event)
+		if event.GaveUp {
+			return
+		}
+
+		Logger.Printf("error subscribing consumer %s to %q again, attempt %d: %+v", tag, config.Queue, attempt, err)
+
+		if delay *= 2; delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
+
+// subscribeAgain checks that the queue exists and sends basic.consume for a
+// consumer that is still registered, so that its deliveries keep flowing to
+// the same buffer.
+func (ch *Channel) subscribeAgain(tag string, config consumerConfig) error {
+	if err := ch.connection.probeQueue(config.Queue); err != nil {
+		return err
+	}
+	return ch.subscribe(tag, config)
+}
+
+// subscribe sends basic.consume for a consumer that is registered already.
+func (ch *Channel) subscribe(tag string, config consumerConfig) error {
+	return ch.call(
+		&basicConsume{
+			Queue:       config.Queue,
+			ConsumerTag: tag,
+			NoLocal:     config.NoLocal,
+			NoAck:       config.AutoAck,
+			Exclusive:   config.Exclusive,
+			NoWait:      config.NoWait,
+			Arguments:   config.recoveryArgs(),
+		},
+		&basicConsumeOk{},
+	)
+}
+
+// probeQueue passively declares queue on a short-lived channel, which the
+// server closes when the queue does not exist.
+func (c *Connection) probeQueue(queue string) error {
+	return c.probe(func(probe *Channel) error {
+		_, err := probe.QueueDeclarePassive(queue, false, false, false, false, nil)
+		return err
+	})
+}
+
+// probe runs check on a short-lived channel, so that the server closing it
+// does not affect other channels.  The channel is not recovered.
+func (c *Connection) probe(check func(probe *Channel) error) error {
+	probe, err := c.allocateChannel()
+	if err != nil {
+		return err
+	}
+	// Closing the channel with an error keeps it registered for recovery.
+	defer c.releaseChannel(probe)
+
+	if err := probe.open(); err != nil {
+		return err
+	}
+	probe.lifeCycle.SetState(StateOpen, nil)
+
+	if err := check(probe); err != nil {
+		return err
+	}
+	return probe.Close()
+}
+
+func (ch *Channel) notifyResubscribe(event ResubscribeEvent) {
+	ch.notifyM.RLock()
+	defer ch.notifyM.RUnlock()
+	notifyAll(ch.resubscribes, event)
+}
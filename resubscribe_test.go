@@ -0,0 +1,212 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"testing"
+	"time"
+)
+
+// serveMissingQueueProbe answers the passive declaration of a probe channel
+// by closing it as when the queue does not exist.
+func serveMissingQueueProbe(t *testing.T, srv *server, id int) {
+	srv.channelOpen(id)
+	declare := &queueDeclare{}
+	srv.recv(id, declare)
+	if !declare.Passive {
+		t.Errorf("expected the queue to be declared passively, got: %+v", declare)
+	}
+	srv.send(id, &channelClose{ReplyCode: NotFound, ReplyText: "NOT_FOUND - no queue"})
+	srv.recv(id, &channelCloseOk{})
+}
+
+func receiveResubscribeEvent(t *testing.T, events <-chan ResubscribeEvent) ResubscribeEvent {
+	t.Helper()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for a resubscribe event")
+	}
+	return ResubscribeEvent{}
+}
+
+func TestResubscribeAfterServerCancel(t *testing.T) {
+	const tag = "resubscribed"
+
+	ready := make(chan struct{})
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		<-ready
+		srv.send(1, &basicCancel{ConsumerTag: tag, NoWait: true})
+
+		// The queue is missing on the first attempt.
+		serveMissingQueueProbe(t, srv, 2)
+
+		srv.channelOpen(3)
+		srv.recv(3, &queueDeclare{})
+		srv.send(3, &queueDeclareOk{Queue: "work"})
+		srv.recv(3, &channelClose{})
+		srv.send(3, &channelCloseOk{})
+
+		consume := &basicConsume{}
+		srv.recv(1, consume)
+		if consume.Queue != "work" || consume.ConsumerTag != tag {
+			t.Errorf("expected to subscribe again with the same tag, got: %+v", consume)
+		}
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Body: []byte("again")})
+	})
+
+	deliveries, err := ch.Consume("work", tag, true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	if err := ch.SetResubscribePolicy("unknown", &ResubscribePolicy{}); err != ErrConsumerNotFound {
+		t.Errorf("expected ErrConsumerNotFound for an unknown consumer, got: %v", err)
+	}
+	if err := ch.SetResubscribePolicy(tag, &ResubscribePolicy{InitialDelay: time.Millisecond}); err != nil {
+		t.Fatalf("could not set resubscribe policy: %v", err)
+	}
+	events := ch.NotifyResubscribe(make(chan ResubscribeEvent, 2))
+	close(ready)
+
+	select {
+	case d, ok := <-deliveries:
+		if !ok {
+			t.Fatal("expected the deliveries to stay open across the server cancel")
+		}
+		if string(d.Body) != "again" {
+			t.Errorf("unexpected delivery: %+v", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for a delivery after resubscribing")
+	}
+
+	first := receiveResubscribeEvent(t, events)
+	if first.Attempt != 1 || first.Err == nil || first.GaveUp || first.Queue != "work" {
+		t.Errorf("expected the first attempt to fail, got: %+v", first)
+	}
+	second := receiveResubscribeEvent(t, events)
+	if second.Attempt != 2 || second.Err != nil || second.Consumer != tag {
+		t.Errorf("expected the second attempt to succeed, got: %+v", second)
+	}
+}
+
+func TestResubscribeGivesUpAfterMaxAttempts(t *testing.T) {
+	const tag = "resubscribed"
+
+	ready := make(chan struct{})
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		<-ready
+		srv.send(1, &basicCancel{ConsumerTag: tag, NoWait: true})
+
+		serveMissingQueueProbe(t, srv, 2)
+	})
+
+	deliveries, err := ch.Consume("work", tag, true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	if err := ch.SetResubscribePolicy(tag, &ResubscribePolicy{MaxAttempts: 1, InitialDelay: time.Millisecond}); err != nil {
+		t.Fatalf("could not set resubscribe policy: %v", err)
+	}
+	events := ch.NotifyResubscribe(make(chan ResubscribeEvent, 1))
+	close(ready)
+
+	e := receiveResubscribeEvent(t, events)
+	if e.Attempt != 1 || e.Err == nil || !e.GaveUp {
+		t.Errorf("expected to give up after the first attempt, got: %+v", e)
+	}
+
+	select {
+	case _, ok := <-deliveries:
+		if ok {
+			t.Error("expected no delivery")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the deliveries to be closed after giving up")
+	}
+
+	if ch.IsClosed() {
+		t.Error("expected the consumer's channel to stay open")
+	}
+}
+
+func TestResubscribeStopsWhenChannelRecovered(t *testing.T) {
+	const tag = "resubscribed"
+
+	ready := make(chan struct{})
+	recovered := make(chan struct{})
+	done := make(chan struct{})
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		defer close(done)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		<-ready
+		srv.send(1, &basicCancel{ConsumerTag: tag, NoWait: true})
+
+		<-recovered
+		srv.channelOpen(1)
+
+		// The only basic.consume is the one of recovery, a probe channel
+		// opened to subscribe again would fail the next recv.
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.recv(1, &channelClose{})
+		srv.send(1, &channelCloseOk{})
+	})
+
+	if _, err := ch.Consume("work", tag, true, false, false, false, nil); err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	const delay = 100 * time.Millisecond
+	if err := ch.SetResubscribePolicy(tag, &ResubscribePolicy{InitialDelay: delay}); err != nil {
+		t.Fatalf("could not set resubscribe policy: %v", err)
+	}
+	cancels := ch.NotifyCancel(make(chan string, 1))
+	close(ready)
+
+	select {
+	case <-cancels:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the server cancel")
+	}
+
+	// Recover the channel during the backoff, subscribing the consumer
+	// again as topology recovery does.
+	ch.reconnecting.Lock()
+	close(recovered)
+	if _, err := ch.openChannelSession(); err != nil {
+		t.Fatalf("could not recover channel: %v", err)
+	}
+	ch.reconnecting.Unlock()
+	config, ok := ch.consumers.configFor(tag)
+	if !ok {
+		t.Fatal("expected the consumer to stay registered")
+	}
+	if err := ch.subscribe(tag, config); err != nil {
+		t.Fatalf("could not subscribe again: %v", err)
+	}
+
+	time.Sleep(2 * delay)
+	if err := ch.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	<-done
+}
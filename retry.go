@@ -0,0 +1,207 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetryAttemptHeader is the header holding the number of times a delivery was
+// scheduled for a retry by RetryTopology.RetryLater.
+const RetryAttemptHeader = "x-retry-attempt"
+
+var (
+	// ErrNoRetryDelays is returned by DeclareRetryTopology when no delay is
+	// configured.
+	ErrNoRetryDelays = errors.New("amqp: retry topology needs at least one delay")
+
+	// ErrRetryNacked is returned by RetryTopology.RetryLater when the broker
+	// negatively acknowledged the republished message.
+	ErrRetryNacked = errors.New("amqp: retried message negatively acknowledged by the broker")
+)
+
+// RetryConfig configures the topology declared by DeclareRetryTopology.
+type RetryConfig struct {
+	// Delays are how long a message waits before it is delivered to the work
+	// queue again, by attempt.  Attempts past the last delay use the last
+	// delay.  At least one delay is required.
+	Delays []time.Duration
+
+	// MaxAttempts is the number of retries before a message is sent to the
+	// parking lot queue.  It defaults to the number of delays.
+	MaxAttempts int
+}
+
+/*
+RetryTopology retries the messages of a work queue after a delay, instead of
+requeueing them straight away or dead-lettering them for good.
+
+DeclareRetryTopology declares, for a work queue named "orders":
+
+	Exchange          Kind    Queues
+	------------------------------------------------------------------
+	orders.retry   -> direct  orders.retry.<delay in ms>, orders.parking-lot
+
+Each delay queue has a message TTL of its delay and dead-letters expired
+messages to the default exchange with the routing key of the work queue, so
+that they are delivered to it again.  The parking lot queue keeps the messages
+that failed too many times for inspection.
+
+The exchange and queues are durable and declared with Channel.ExchangeDeclare,
+Channel.QueueDeclare and Channel.QueueBind, so they are recorded for topology
+recovery along with the rest of the channel's topology.
+*/
+type RetryTopology struct {
+	ch          *Channel
+	queue       string
+	exchange    string
+	parkingLot  string
+	delays      []time.Duration
+	maxAttempts int
+}
+
+// DeclareRetryTopology declares the retry topology of queue on ch.
+func DeclareRetryTopology(ch *Channel, queue string, config RetryConfig) (*RetryTopology, error) {
+	if len(config.Delays) == 0 {
+		return nil, ErrNoRetryDelays
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = len(config.Delays)
+	}
+
+	r := &RetryTopology{
+		ch:          ch,
+		queue:       queue,
+		exchange:    queue + ".retry",
+		parkingLot:  queue + ".parking-lot",
+		delays:      append([]time.Duration(nil), config.Delays...),
+		maxAttempts: config.MaxAttempts,
+	}
+
+	if err := ch.ExchangeDeclare(r.exchange, ExchangeDirect, true, false, false, false, nil); err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool)
+	for _, delay := range r.delays {
+		name := r.delayQueue(delay)
+		if declared[name] {
+			continue
+		}
+		declared[name] = true
+
+		args := Table{
+			QueueMessageTTLArg:           delay.Milliseconds(),
+			QueueDeadLetterExchangeArg:   "",
+			QueueDeadLetterRoutingKeyArg: queue,
+		}
+		if err := r.declareAndBind(name, args); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.declareAndBind(r.parkingLot, nil); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *RetryTopology) declareAndBind(name string, args Table) error {
+	if _, err := r.ch.QueueDeclare(name, true, false, false, false, args); err != nil {
+		return err
+	}
+	return r.ch.QueueBind(name, name, r.exchange, false, nil)
+}
+
+func (r *RetryTopology) delayQueue(delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%d", r.queue, delay.Milliseconds())
+}
+
+// Exchange returns the name of the exchange routing to the delay and parking
+// lot queues.
+func (r *RetryTopology) Exchange() string {
+	return r.exchange
+}
+
+// ParkingLot returns the name of the queue holding the messages that were
+// retried MaxAttempts times.
+func (r *RetryTopology) ParkingLot() string {
+	return r.parkingLot
+}
+
+// RetryAttempt returns the RetryAttemptHeader of a delivery, which is 0 when
+// it was never retried.
+func RetryAttempt(d Delivery) int {
+	n, _ := headerInt(d.Headers[RetryAttemptHeader])
+	return int(n)
+}
+
+/*
+RetryLater republishes a delivery that failed to the delay queue for attempt,
+with its RetryAttemptHeader set to attempt, and then acknowledges it.  Attempts
+start at 1, so a handler would usually pass RetryAttempt(d)+1.  Past
+MaxAttempts, the message is republished to the parking lot queue instead.
+
+The message keeps its properties and headers, except for Expiration, which
+would compete with the delay queue's TTL.  When the channel is in confirm mode,
+the delivery is only acknowledged once the republished message is confirmed.
+When RetryLater returns an error, the delivery is left unacknowledged.
+*/
+func (r *RetryTopology) RetryLater(d Delivery, attempt int) error {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	key := r.parkingLot
+	if attempt <= r.maxAttempts {
+		i := attempt - 1
+		if i >= len(r.delays) {
+			i = len(r.delays) - 1
+		}
+		key = r.delayQueue(r.delays[i])
+	}
+
+	headers := make(Table, len(d.Headers)+1)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[RetryAttemptHeader] = int64(attempt)
+
+	msg := Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		UserId:          d.UserId,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+
+	ctx := context.Background()
+	dc, err := r.ch.PublishWithDeferredConfirmWithContext(ctx, r.exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	if dc != nil {
+		acked, err := dc.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !acked {
+			return ErrRetryNacked
+		}
+	}
+
+	return d.Ack(false)
+}
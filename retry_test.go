@@ -0,0 +1,164 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeclareRetryTopology(t *testing.T) {
+	type declared struct {
+		exchange *exchangeDeclare
+		queues   []*queueDeclare
+		binds    []*queueBind
+	}
+	results := make(chan declared, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r declared
+
+		r.exchange = &exchangeDeclare{}
+		srv.recv(1, r.exchange)
+		srv.send(1, &exchangeDeclareOk{})
+
+		// Two distinct delays and the parking lot.
+		for i := 0; i < 3; i++ {
+			q := &queueDeclare{}
+			srv.recv(1, q)
+			srv.send(1, &queueDeclareOk{Queue: q.Queue})
+			r.queues = append(r.queues, q)
+
+			b := &queueBind{}
+			srv.recv(1, b)
+			srv.send(1, &queueBindOk{})
+			r.binds = append(r.binds, b)
+		}
+
+		results <- r
+	})
+
+	rt, err := DeclareRetryTopology(ch, "orders", RetryConfig{
+		Delays: []time.Duration{time.Second, 10 * time.Second, 10 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("could not declare retry topology: %v", err)
+	}
+
+	r := <-results
+	if r.exchange.Exchange != "orders.retry" || r.exchange.Type != ExchangeDirect || !r.exchange.Durable {
+		t.Errorf("unexpected retry exchange: %+v", r.exchange)
+	}
+	if rt.Exchange() != "orders.retry" || rt.ParkingLot() != "orders.parking-lot" {
+		t.Errorf("unexpected names: %q, %q", rt.Exchange(), rt.ParkingLot())
+	}
+
+	wantQueues := []string{"orders.retry.1000", "orders.retry.10000", "orders.parking-lot"}
+	for i, want := range wantQueues {
+		if r.queues[i].Queue != want || !r.queues[i].Durable {
+			t.Errorf("expected durable queue %q, got: %+v", want, r.queues[i])
+		}
+		if r.binds[i].Queue != want || r.binds[i].RoutingKey != want || r.binds[i].Exchange != "orders.retry" {
+			t.Errorf("expected %q to be bound to the retry exchange, got: %+v", want, r.binds[i])
+		}
+	}
+
+	args := r.queues[0].Arguments
+	if args[QueueMessageTTLArg] != int64(1000) {
+		t.Errorf("expected a TTL of 1000ms, got: %#v", args[QueueMessageTTLArg])
+	}
+	if args[QueueDeadLetterExchangeArg] != "" || args[QueueDeadLetterRoutingKeyArg] != "orders" {
+		t.Errorf("expected expired messages to be dead-lettered to the work queue, got: %#v", args)
+	}
+	if len(r.queues[2].Arguments) != 0 {
+		t.Errorf("expected the parking lot to have no arguments, got: %#v", r.queues[2].Arguments)
+	}
+
+	if _, err := DeclareRetryTopology(ch, "orders", RetryConfig{}); err != ErrNoRetryDelays {
+		t.Errorf("expected ErrNoRetryDelays without delays, got: %v", err)
+	}
+}
+
+func TestRetryLaterRepublishesAndAcks(t *testing.T) {
+	const tag = "retried"
+
+	type retried struct {
+		publishes []*basicPublish
+		acks      []*basicAck
+	}
+	results := make(chan retried, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r retried
+
+		srv.recv(1, &exchangeDeclare{})
+		srv.send(1, &exchangeDeclareOk{})
+		for i := 0; i < 2; i++ {
+			q := &queueDeclare{}
+			srv.recv(1, q)
+			srv.send(1, &queueDeclareOk{Queue: q.Queue})
+			srv.recv(1, &queueBind{})
+			srv.send(1, &queueBindOk{})
+		}
+
+		serveDeliveries(srv, tag, 2)
+
+		for i := 0; i < 2; i++ {
+			pub := &basicPublish{}
+			srv.recv(1, pub)
+			r.publishes = append(r.publishes, pub)
+
+			ack := &basicAck{}
+			srv.recv(1, ack)
+			r.acks = append(r.acks, ack)
+		}
+
+		results <- r
+	})
+
+	rt, err := DeclareRetryTopology(ch, "orders", RetryConfig{Delays: []time.Duration{time.Second}, MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("could not declare retry topology: %v", err)
+	}
+
+	deliveries, err := ch.Consume("orders", tag, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+	ds := receiveDeliveries(t, deliveries, 2)
+
+	ds[0].Headers = Table{"trace": "abc"}
+	if got := RetryAttempt(ds[0]); got != 0 {
+		t.Errorf("expected no retry attempt yet, got %d", got)
+	}
+	if err := rt.RetryLater(ds[0], RetryAttempt(ds[0])+1); err != nil {
+		t.Fatalf("unexpected retry error: %v", err)
+	}
+
+	ds[1].Headers = Table{RetryAttemptHeader: int64(2)}
+	if err := rt.RetryLater(ds[1], RetryAttempt(ds[1])+1); err != nil {
+		t.Fatalf("unexpected retry error: %v", err)
+	}
+
+	r := <-results
+
+	first := r.publishes[0]
+	if first.Exchange != "orders.retry" || first.RoutingKey != "orders.retry.1000" {
+		t.Errorf("expected the first retry to go to the delay queue, got: %+v", first)
+	}
+	if first.Properties.Headers[RetryAttemptHeader] != int64(1) || first.Properties.Headers["trace"] != "abc" {
+		t.Errorf("expected the headers to be kept and the attempt set, got: %#v", first.Properties.Headers)
+	}
+	if r.acks[0].DeliveryTag != 1 || r.acks[0].Multiple {
+		t.Errorf("expected delivery 1 to be acknowledged, got: %+v", r.acks[0])
+	}
+
+	parked := r.publishes[1]
+	if parked.RoutingKey != "orders.parking-lot" || parked.Properties.Headers[RetryAttemptHeader] != int64(3) {
+		t.Errorf("expected the third attempt to go to the parking lot, got: %+v", parked)
+	}
+	if r.acks[1].DeliveryTag != 2 {
+		t.Errorf("expected delivery 2 to be acknowledged, got: %+v", r.acks[1])
+	}
+}
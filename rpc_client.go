@@ -0,0 +1,264 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DirectReplyTo is the pseudo-queue used for RabbitMQ direct reply-to.
+// Consuming from it in no-ack mode and publishing with ReplyTo set to it lets
+// replies be delivered straight back to the requesting channel without
+// declaring a reply queue.  See https://www.rabbitmq.com/docs/direct-reply-to
+const DirectReplyTo = "amq.rabbitmq.reply-to"
+
+// ErrCallInterrupted is returned by RPCClient.Call for calls that were in
+// flight when the client's channel started recovering, as their replies are
+// lost with the old channel.
+var ErrCallInterrupted = errors.New("amqp: RPC call interrupted by channel recovery")
+
+// UnroutableError is returned by RPCClient.Call when the server returns the
+// request because it could not be routed to any queue.
+type UnroutableError struct {
+	Return Return
+}
+
+func (e *UnroutableError) Error() string {
+	return fmt.Sprintf("amqp: RPC request to exchange %q with routing key %q was returned: %d %s",
+		e.Return.Exchange, e.Return.RoutingKey, e.Return.ReplyCode, e.Return.ReplyText)
+}
+
+type rpcResult struct {
+	delivery Delivery
+	err      error
+}
+
+// RPCClient sends requests and waits for their replies using direct reply-to.
+// It owns a dedicated channel that consumes DirectReplyTo in no-ack mode, and
+// correlates replies with requests through their CorrelationId.  An RPCClient
+// is safe for concurrent use.
+type RPCClient struct {
+	ch *Channel
+
+	// Timeout, if positive, bounds every call in addition to the context
+	// passed to Call.
+	Timeout time.Duration
+
+	prefix string
+	seq    atomic.Uint64
+
+	m       sync.Mutex
+	pending map[string]chan rpcResult
+	err     error // set once the client is closed
+}
+
+// NewRPCClient opens a channel on conn and starts consuming replies from
+// DirectReplyTo on it.  Close the client to release the channel.
+func NewRPCClient(conn *Connection) (*RPCClient, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	// Register before consuming so no transition is missed.
+	states := make(chan *StateChanged, 8)
+	ch.NotifyStateChange(states)
+	returns := ch.NotifyReturn(make(chan Return, 8))
+	closes := ch.NotifyClose(make(chan *Error, 1))
+
+	replies, err := ch.Consume(DirectReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		_ = ch.Close()
+		return nil, err
+	}
+
+	c := &RPCClient{
+		ch:      ch,
+		prefix:  "rpc-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-",
+		pending: make(map[string]chan rpcResult),
+	}
+
+	go c.dispatch(replies, returns, closes, states)
+
+	return c, nil
+}
+
+/*
+Call publishes msg to exchange with routing key key and waits for the reply.
+
+msg.ReplyTo is set to DirectReplyTo, and msg.CorrelationId is generated
+unless it is already set, in which case it must be unique among the client's
+calls in flight.  The request is published as mandatory: if the server cannot
+route it, Call returns an *UnroutableError as soon as it is returned instead
+of waiting for a reply that will never come.
+
+If the reply carries the RPCErrorHeader header, as the error replies sent by
+RPCServer do, Call returns the reply along with an *RPCError.
+
+Call returns when the reply arrives, when ctx is done or the client's Timeout
+expires, or when the client's channel starts recovering or closes, in which
+case ErrCallInterrupted or the closing error is returned.  A reply arriving
+after Call has returned is discarded.
+*/
+func (c *RPCClient) Call(ctx context.Context, exchange, key string, msg Publishing) (Delivery, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	if msg.CorrelationId == "" {
+		msg.CorrelationId = c.prefix + strconv.FormatUint(c.seq.Add(1), 10)
+	}
+	msg.ReplyTo = DirectReplyTo
+
+	if err := ctx.Err(); err != nil {
+		return Delivery{}, err
+	}
+
+	var (
+		result  chan rpcResult
+		callErr error
+	)
+
+	// Register the call again on every attempt, as a failed attempt may have
+	// been interrupted by the recovery it waits for.
+	err := c.ch.awaitRecovery(ctx, func() error {
+		result = make(chan rpcResult, 1)
+
+		c.m.Lock()
+		if c.err != nil {
+			callErr = c.err
+			c.m.Unlock()
+			return nil
+		}
+		if _, found := c.pending[msg.CorrelationId]; found {
+			c.m.Unlock()
+			return fmt.Errorf("amqp: RPC call with correlation id %q already in flight", msg.CorrelationId)
+		}
+		c.pending[msg.CorrelationId] = result
+		c.m.Unlock()
+
+		if _, err := c.ch.publish(ctx, 0, exchange, key, true, false, msg); err != nil {
+			c.forget(msg.CorrelationId)
+			return err
+		}
+		return nil
+	})
+	if callErr != nil {
+		return Delivery{}, callErr
+	}
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	select {
+	case res := <-result:
+		return res.delivery, res.err
+	case <-ctx.Done():
+		c.forget(msg.CorrelationId)
+		return Delivery{}, ctx.Err()
+	}
+}
+
+// Close closes the client's channel, failing calls in flight with ErrClosed.
+func (c *RPCClient) Close() error {
+	return c.ch.Close()
+}
+
+func (c *RPCClient) forget(correlationId string) {
+	c.m.Lock()
+	delete(c.pending, correlationId)
+	c.m.Unlock()
+}
+
+// complete hands the result to the call waiting for correlationId, if any.
+func (c *RPCClient) complete(correlationId string, res rpcResult) {
+	c.m.Lock()
+	result, found := c.pending[correlationId]
+	delete(c.pending, correlationId)
+	c.m.Unlock()
+
+	if found {
+		result <- res
+	}
+}
+
+// failAll fails every call in flight with err, and every later call too when
+// final is set.
+func (c *RPCClient) failAll(err error, final bool) {
+	c.m.Lock()
+	pending := c.pending
+	c.pending = make(map[string]chan rpcResult)
+	if final && c.err == nil {
+		c.err = err
+	}
+	c.m.Unlock()
+
+	for _, result := range pending {
+		result <- rpcResult{err: err}
+	}
+}
+
+func (c *RPCClient) dispatch(replies <-chan Delivery, returns <-chan Return, closes <-chan *Error, states <-chan *StateChanged) {
+	// The closing error, if any, is sent before the consumer is closed.
+	var closeErr error = ErrClosed
+
+	for replies != nil || states != nil {
+		select {
+		case d, ok := <-replies:
+			if !ok {
+				select {
+				case e, ok := <-closes:
+					if ok && e != nil {
+						closeErr = e
+					}
+				default:
+				}
+				replies = nil
+				c.failAll(closeErr, true)
+				continue
+			}
+			c.complete(d.CorrelationId, rpcResult{delivery: d, err: rpcReplyError(d)})
+
+		case e, ok := <-closes:
+			if !ok {
+				closes = nil
+				continue
+			}
+			if e != nil {
+				closeErr = e
+			}
+
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.complete(r.CorrelationId, rpcResult{err: &UnroutableError{Return: r}})
+
+		case sc, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			switch sc.To {
+			case StateReconnecting:
+				c.failAll(ErrCallInterrupted, false)
+			case StateClosed:
+				err := sc.Err
+				if err == nil {
+					err = ErrClosed
+				}
+				c.failAll(err, true)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// rpcClientSession opens a connection to srv and an RPCClient on it, with the
+// server side of the reply consumer handled.
+func rpcClientSession(t *testing.T, serve func(srv *server, replyTag string)) *RPCClient {
+	t.Helper()
+
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		consume := &basicConsume{}
+		srv.recv(1, consume)
+		if consume.Queue != DirectReplyTo || !consume.NoAck {
+			t.Errorf("expected a no-ack consumer on %s, got: %+v", DirectReplyTo, consume)
+		}
+		srv.send(1, &basicConsumeOk{ConsumerTag: consume.ConsumerTag})
+
+		serve(srv, consume.ConsumerTag)
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	client, err := NewRPCClient(c)
+	if err != nil {
+		t.Fatalf("could not create RPC client: %v", err)
+	}
+
+	return client
+}
+
+func TestRPCClientCall(t *testing.T) {
+	client := rpcClientSession(t, func(srv *server, replyTag string) {
+		req := &basicPublish{}
+		srv.recv(1, req)
+		if !req.Mandatory || req.Properties.ReplyTo != DirectReplyTo || req.Properties.CorrelationId == "" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+
+		srv.send(1, &basicDeliver{
+			ConsumerTag: replyTag,
+			DeliveryTag: 1,
+			Properties:  properties{CorrelationId: req.Properties.CorrelationId},
+			Body:        append([]byte("re: "), req.Body...),
+		})
+	})
+
+	reply, err := client.Call(context.TODO(), "", "rpc", Publishing{Body: []byte("ping")})
+	if err != nil {
+		t.Fatalf("unexpected call error: %v", err)
+	}
+	if string(reply.Body) != "re: ping" {
+		t.Errorf("unexpected reply body: %q", reply.Body)
+	}
+}
+
+func TestRPCClientCallUnroutable(t *testing.T) {
+	client := rpcClientSession(t, func(srv *server, replyTag string) {
+		req := &basicPublish{}
+		srv.recv(1, req)
+
+		srv.send(1, &basicReturn{
+			ReplyCode:  NoRoute,
+			ReplyText:  "NO_ROUTE",
+			RoutingKey: req.RoutingKey,
+			Properties: req.Properties,
+			Body:       req.Body,
+		})
+	})
+
+	_, err := client.Call(context.TODO(), "", "nowhere", Publishing{Body: []byte("ping")})
+
+	var unroutable *UnroutableError
+	if !errors.As(err, &unroutable) {
+		t.Fatalf("expected an UnroutableError, got: %v", err)
+	}
+	if unroutable.Return.ReplyCode != NoRoute || unroutable.Return.RoutingKey != "nowhere" {
+		t.Errorf("unexpected return: %+v", unroutable.Return)
+	}
+}
+
+func TestRPCClientCallTimeout(t *testing.T) {
+	client := rpcClientSession(t, func(srv *server, replyTag string) {
+		srv.recv(1, &basicPublish{})
+	})
+	client.Timeout = 50 * time.Millisecond
+
+	if _, err := client.Call(context.TODO(), "", "slow", Publishing{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the call to time out, got: %v", err)
+	}
+
+	client.m.Lock()
+	pending := len(client.pending)
+	client.m.Unlock()
+	if pending != 0 {
+		t.Errorf("expected the timed out call to be forgotten, %d calls pending", pending)
+	}
+}
+
+func TestRPCClientCallFailsWhenChannelCloses(t *testing.T) {
+	client := rpcClientSession(t, func(srv *server, replyTag string) {
+		srv.recv(1, &basicPublish{})
+		srv.send(1, &channelClose{ReplyCode: NotFound, ReplyText: "NOT_FOUND"})
+		srv.recv(1, &channelCloseOk{})
+	})
+
+	_, err := client.Call(context.TODO(), "missing", "rpc", Publishing{})
+
+	var amqpErr *Error
+	if !errors.As(err, &amqpErr) || amqpErr.Code != NotFound {
+		t.Fatalf("expected the call to fail with the channel error, got: %v", err)
+	}
+
+	if _, err := client.Call(context.TODO(), "", "rpc", Publishing{}); err == nil {
+		t.Fatal("expected calls on a closed client to fail")
+	}
+}
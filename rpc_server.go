@@ -0,0 +1,232 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RPCErrorHeader is the header set on error replies sent by RPCServer, holding
+// the error returned by the handler or the value it panicked with.
+const RPCErrorHeader = "x-rpc-error"
+
+// RPCError is returned by RPCClient.Call when the reply is an error reply,
+// marked with the RPCErrorHeader header, such as the replies RPCServer sends
+// when its handler fails.
+type RPCError struct {
+	Message string
+	Reply   Delivery
+}
+
+func (e *RPCError) Error() string {
+	return "amqp: RPC handler failed: " + e.Message
+}
+
+// rpcReplyError returns the RPCError carried by a reply, if any.
+func rpcReplyError(reply Delivery) error {
+	msg, ok := reply.Headers[RPCErrorHeader]
+	if !ok {
+		return nil
+	}
+	return &RPCError{Message: fmt.Sprint(msg), Reply: reply}
+}
+
+// RPCHandler handles a request received by an RPCServer and returns the
+// reply.  ctx is cancelled when a Shutdown of the server gives up waiting.
+type RPCHandler func(ctx context.Context, request Delivery) (Publishing, error)
+
+// ErrServerStarted is returned by RPCServer.Start when the server was already
+// started.
+var ErrServerStarted = errors.New("amqp: RPC server already started")
+
+/*
+RPCServer consumes requests from a queue, invokes a handler for each of them
+and publishes the reply to the request's ReplyTo with its CorrelationId.
+
+A request is acknowledged only after its reply has been published, and when
+ConfirmReplies is set, only after the server has confirmed the reply.  If the
+reply cannot be published or is negatively confirmed, the request is requeued.
+Requests without a ReplyTo are acknowledged once handled.
+
+When the handler returns an error or panics, an error reply with an empty body
+and the error in the RPCErrorHeader header is sent instead, which RPCClient
+turns into an *RPCError.  The request is still acknowledged.
+
+Set the exported fields before calling Start.
+*/
+type RPCServer struct {
+	ch      *Channel
+	queue   string
+	handler RPCHandler
+
+	// Concurrency is the number of requests handled at the same time.  The
+	// channel's prefetch count is set to match.  It defaults to 1.
+	Concurrency int
+
+	// ConfirmReplies puts the channel in confirm mode and waits for each
+	// reply to be confirmed before acknowledging its request.
+	ConfirmReplies bool
+
+	// Consumer is the consumer tag, generated when empty.
+	Consumer string
+
+	m       sync.Mutex
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRPCServer returns an RPCServer that handles requests from queue on ch
+// with handler.  The server should have the channel to itself.
+func NewRPCServer(ch *Channel, queue string, handler RPCHandler) *RPCServer {
+	return &RPCServer{
+		ch:          ch,
+		queue:       queue,
+		handler:     handler,
+		Concurrency: 1,
+	}
+}
+
+// Start sets up the channel and starts consuming and handling requests.
+func (s *RPCServer) Start() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.started {
+		return ErrServerStarted
+	}
+
+	concurrency := s.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	if err := s.ch.Qos(concurrency, 0, false); err != nil {
+		return err
+	}
+
+	if s.ConfirmReplies {
+		if err := s.ch.Confirm(false); err != nil {
+			return err
+		}
+	}
+
+	if s.Consumer == "" {
+		s.Consumer = uniqueConsumerTag()
+	}
+
+	requests, err := s.ch.Consume(s.queue, s.Consumer, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	s.started = true
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < concurrency; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for d := range requests {
+				s.handle(d)
+			}
+		}()
+	}
+
+	return nil
+}
+
+/*
+Shutdown stops consuming new requests and waits for the requests already
+received, including those prefetched but not yet handled, to be handled and
+replied to.  If ctx is done first, the context passed to handlers in flight
+is cancelled and ctx's error is returned.  Shutdown does not close the channel.
+*/
+func (s *RPCServer) Shutdown(ctx context.Context) error {
+	s.m.Lock()
+	started := s.started
+	s.m.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	cancelErr := s.ch.Cancel(s.Consumer, false)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		if errors.Is(cancelErr, ErrClosed) {
+			// The deliveries were closed along with the channel.
+			return nil
+		}
+		return cancelErr
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// handle processes a single request.
+func (s *RPCServer) handle(request Delivery) {
+	reply, err := s.invoke(request)
+
+	if request.ReplyTo == "" {
+		if aerr := request.Ack(false); aerr != nil {
+			Logger.Printf("error acknowledging RPC request %d: %+v", request.DeliveryTag, aerr)
+		}
+		return
+	}
+
+	if err != nil {
+		reply = Publishing{Headers: Table{RPCErrorHeader: err.Error()}}
+	}
+	reply.CorrelationId = request.CorrelationId
+
+	if err := s.reply(request.ReplyTo, reply); err != nil {
+		Logger.Printf("error replying to RPC request %d, requeueing it: %+v", request.DeliveryTag, err)
+		if nerr := request.Nack(false, true); nerr != nil {
+			Logger.Printf("error requeueing RPC request %d: %+v", request.DeliveryTag, nerr)
+		}
+		return
+	}
+
+	if aerr := request.Ack(false); aerr != nil {
+		Logger.Printf("error acknowledging RPC request %d: %+v", request.DeliveryTag, aerr)
+	}
+}
+
+// invoke calls the handler, turning a panic into an error.
+func (s *RPCServer) invoke(request Delivery) (reply Publishing, err error) {
+	defer recoverHandler(&err)
+	return s.handler(s.ctx, request)
+}
+
+// reply publishes the reply through the default exchange, waiting for its
+// confirmation when ConfirmReplies is set.
+func (s *RPCServer) reply(replyTo string, reply Publishing) error {
+	dc, err := s.ch.PublishWithDeferredConfirmWithContext(s.ctx, "", replyTo, false, false, reply)
+	if err != nil || dc == nil {
+		return err
+	}
+
+	acked, err := dc.WaitContext(s.ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("amqp: reply negatively acknowledged by the server")
+	}
+	return nil
+}
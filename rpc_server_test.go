@@ -0,0 +1,180 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRPCServerRepliesAndAcks(t *testing.T) {
+	const tag = "rpc-server"
+
+	type result struct {
+		qos   *basicQos
+		reply *basicPublish
+		ack   *basicAck
+	}
+	results := make(chan result, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r result
+
+		r.qos = &basicQos{}
+		srv.recv(1, r.qos)
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{
+			ConsumerTag: tag,
+			DeliveryTag: 1,
+			Properties:  properties{ReplyTo: "amq.rabbitmq.reply-to.abc", CorrelationId: "c-1"},
+			Body:        []byte("ping"),
+		})
+
+		r.reply = &basicPublish{}
+		srv.recv(1, r.reply)
+		r.ack = &basicAck{}
+		srv.recv(1, r.ack)
+		results <- r
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+	})
+
+	s := NewRPCServer(ch, "rpc", func(ctx context.Context, request Delivery) (Publishing, error) {
+		return Publishing{Body: append([]byte("re: "), request.Body...)}, nil
+	})
+	s.Concurrency = 4
+	s.Consumer = tag
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("could not start RPC server: %v", err)
+	}
+
+	r := <-results
+	if r.qos.PrefetchCount != 4 {
+		t.Errorf("expected prefetch to match the concurrency, got %d", r.qos.PrefetchCount)
+	}
+	if r.reply.Exchange != "" || r.reply.RoutingKey != "amq.rabbitmq.reply-to.abc" {
+		t.Errorf("expected the reply to be sent to the request's ReplyTo, got exchange %q and key %q", r.reply.Exchange, r.reply.RoutingKey)
+	}
+	if r.reply.Properties.CorrelationId != "c-1" || string(r.reply.Body) != "re: ping" {
+		t.Errorf("unexpected reply: %+v", r.reply)
+	}
+	if r.ack.DeliveryTag != 1 || r.ack.Multiple {
+		t.Errorf("expected the request to be acknowledged after the reply, got: %+v", r.ack)
+	}
+
+	if err := s.Shutdown(context.TODO()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestRPCServerErrorReplies(t *testing.T) {
+	const tag = "rpc-server"
+
+	replies := make(chan *basicPublish, 2)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		for i, body := range []string{"fail", "panic"} {
+			srv.send(1, &basicDeliver{
+				ConsumerTag: tag,
+				DeliveryTag: uint64(i + 1),
+				Properties:  properties{ReplyTo: "reply", CorrelationId: body},
+				Body:        []byte(body),
+			})
+
+			reply := &basicPublish{}
+			srv.recv(1, reply)
+			srv.recv(1, &basicAck{})
+			replies <- reply
+		}
+	})
+
+	s := NewRPCServer(ch, "rpc", func(ctx context.Context, request Delivery) (Publishing, error) {
+		if string(request.Body) == "panic" {
+			panic("boom")
+		}
+		return Publishing{Body: []byte("ignored")}, errors.New("bad request")
+	})
+	s.Consumer = tag
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("could not start RPC server: %v", err)
+	}
+
+	for _, want := range []string{"bad request", "panic: boom"} {
+		reply := <-replies
+		if got := reply.Properties.Headers[RPCErrorHeader]; got != want {
+			t.Errorf("expected error header %q, got %v", want, got)
+		}
+		if len(reply.Body) != 0 {
+			t.Errorf("expected an error reply without body, got %q", reply.Body)
+		}
+
+		var rpcErr *RPCError
+		if err := rpcReplyError(Delivery{Headers: reply.Properties.Headers}); !errors.As(err, &rpcErr) || rpcErr.Message != want {
+			t.Errorf("expected the client to see an RPCError with message %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestRPCServerConfirmsRepliesBeforeAck(t *testing.T) {
+	const tag = "rpc-server"
+
+	acked := make(chan *basicAck, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{
+			ConsumerTag: tag,
+			DeliveryTag: 1,
+			Properties:  properties{ReplyTo: "reply", CorrelationId: "c-1"},
+		})
+
+		srv.recv(1, &basicPublish{})
+		// Confirm the reply, publisher sequence number 1.
+		srv.send(1, &basicAck{DeliveryTag: 1})
+
+		ack := &basicAck{}
+		srv.recv(1, ack)
+		acked <- ack
+	})
+
+	s := NewRPCServer(ch, "rpc", func(ctx context.Context, request Delivery) (Publishing, error) {
+		return Publishing{}, nil
+	})
+	s.Consumer = tag
+	s.ConfirmReplies = true
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("could not start RPC server: %v", err)
+	}
+
+	if ack := <-acked; ack.DeliveryTag != 1 {
+		t.Errorf("expected request 1 to be acknowledged, got: %+v", ack)
+	}
+
+	if err := s.Start(); err != ErrServerStarted {
+		t.Errorf("expected ErrServerStarted starting twice, got: %v", err)
+	}
+}
@@ -46,3 +46,30 @@ func (log *logIO) Write(p []byte) (n int, err error) {
 func (log *logIO) Close() (err error) {
 	return log.proxy.Close()
 }
+
+// openServedChannel opens a channel with config on a session whose server side,
+// once the channel is open, is handled by serve.
+func openServedChannel(t *testing.T, config Config, serve func(srv *server)) *Channel {
+	t.Helper()
+
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+		serve(srv)
+	}()
+
+	c, err := Open(rwc, config)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	return ch
+}
@@ -0,0 +1,748 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull is returned by PublishSpool.Publish when a publishing has to
+// be spooled but would grow the backlog beyond SpoolConfig.MaxBytes.
+var ErrSpoolFull = errors.New("amqp: publish spool is full")
+
+// ErrSpoolClosed is returned by PublishSpool.Publish after the spool was closed.
+var ErrSpoolClosed = errors.New("amqp: publish spool is closed")
+
+// SpoolSync selects when the spool's files are flushed to stable storage.
+type SpoolSync int
+
+const (
+	// SpoolSyncAlways syncs after every spooled publishing and checkpoint, so
+	// no acknowledged Publish is lost if the host crashes.
+	SpoolSyncAlways SpoolSync = iota
+	// SpoolSyncInterval syncs at most once every SpoolConfig.SyncInterval,
+	// when a publishing is spooled, and when the spool is closed.  Publishings
+	// spooled since the last sync can be lost if the host crashes.
+	SpoolSyncInterval
+	// SpoolSyncNever leaves syncing to the operating system.
+	SpoolSyncNever
+)
+
+// Default SpoolConfig values.
+const (
+	DefaultSpoolSegmentSize   = 64 << 20
+	DefaultSpoolSyncInterval  = time.Second
+	DefaultSpoolRetryInterval = 5 * time.Second
+)
+
+// SpoolConfig configures a PublishSpool.
+type SpoolConfig struct {
+	// Dir is the directory holding the spool's segment files and checkpoint.
+	// It is created if missing and must not be shared with another spool.
+	Dir string
+
+	// SegmentSize is the size in bytes above which a new segment file is
+	// started.  Fully replayed segments are deleted.  It defaults to
+	// DefaultSpoolSegmentSize.
+	SegmentSize int64
+
+	// MaxBytes caps the size in bytes of the spooled records not yet
+	// replayed.  0 means unlimited.
+	MaxBytes int64
+
+	// Sync selects when files are synced to stable storage.
+	Sync SpoolSync
+
+	// SyncInterval is used with SpoolSyncInterval.  It defaults to
+	// DefaultSpoolSyncInterval.
+	SyncInterval time.Duration
+
+	// ConfirmTimeout bounds the wait for the confirmation of a publishing.
+	// A publishing that is not confirmed in time is spooled, or kept in the
+	// spool when replaying.  0 means no timeout.
+	ConfirmTimeout time.Duration
+
+	// RetryInterval is the delay before replaying again after a replayed
+	// publishing failed while the channel stayed open.  It defaults to
+	// DefaultSpoolRetryInterval.
+	RetryInterval time.Duration
+}
+
+// SpoolBacklog describes the publishings waiting in a PublishSpool.
+type SpoolBacklog struct {
+	Messages int   // publishings waiting to be replayed
+	Bytes    int64 // size of their records
+	Segments int   // segment files on disk
+}
+
+/*
+PublishSpool is a write-ahead spool in front of a Channel in confirm mode.
+Publishings that cannot be sent or are not confirmed, for example while the
+connection is recovering or the broker is unreachable, are appended to a local
+log of segment files instead of being lost.  They are replayed in order, each
+waiting for its confirmation, once the channel transitions to StateOpen.
+
+While the spool holds publishings or the channel is not open, new publishings
+are appended to it as well, so they are sent after the spooled ones.  Delivery
+is at least once: a publishing whose confirmation is lost is sent again, and a
+publishing that is spooled after failing its confirmation may end up after
+publishings sent directly in the meantime by other goroutines.
+
+Each record in a segment holds its length, a CRC-32 checksum and the
+publishing encoded as the AMQP frames it is sent as.  The position of the next
+record to replay is kept in a checkpoint file, and an incomplete record at the
+end of the last segment, left by a crash, is discarded when the spool is
+opened.
+*/
+type PublishSpool struct {
+	ch     *Channel
+	config SpoolConfig
+
+	m        sync.Mutex
+	segments []uint64 // segment ids on disk, oldest first, the last one is being written
+	w        *os.File // last segment
+	wSize    int64
+	readSeg  uint64 // segment of the next record to replay
+	readOff  int64  // offset of the next record to replay
+	messages int
+	bytes    int64
+	lastSync time.Time
+	closed   bool
+
+	trigger chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+const (
+	spoolSegmentExt    = ".seg"
+	spoolCheckpoint    = "checkpoint"
+	spoolRecordHeader  = 8 // length and checksum
+	spoolCheckpointLen = 16
+
+	// spoolMaxRecordSize bounds the payload of a record, above the largest
+	// message RabbitMQ accepts, so that a corrupt length is not allocated.
+	spoolMaxRecordSize = 1 << 30
+)
+
+// NewPublishSpool opens the spool in config.Dir, puts ch in confirm mode and
+// starts replaying any publishings left from a previous run.
+func NewPublishSpool(ch *Channel, config SpoolConfig) (*PublishSpool, error) {
+	if config.Dir == "" {
+		return nil, errors.New("amqp: publish spool directory is required")
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = DefaultSpoolSegmentSize
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = DefaultSpoolSyncInterval
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = DefaultSpoolRetryInterval
+	}
+
+	if err := os.MkdirAll(config.Dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	s := &PublishSpool{
+		ch:       ch,
+		config:   config,
+		trigger:  make(chan struct{}, 1),
+		lastSync: time.Now(),
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		_ = s.w.Close()
+		return nil, err
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	states := make(chan *StateChanged, 8)
+	ch.NotifyStateChange(states)
+
+	s.wg.Add(2)
+	go s.watch(states)
+	go s.replayLoop()
+
+	s.kick()
+
+	return s, nil
+}
+
+/*
+Publish sends a publishing on the spool's channel and waits for its
+confirmation.  If the spool already holds publishings or the channel is not
+open, or the publishing cannot be sent, is negatively confirmed or is not
+confirmed in time, it is appended to the spool instead and Publish returns
+nil.  Publish only returns an error when the publishing could not be spooled,
+in which case it was neither sent nor stored.
+
+The spool is not locked while publishing, so concurrent calls to Publish are
+sent concurrently, and Backlog and Close don't wait for publishings blocked by
+the flow control of the server.  A publishing sent while the channel starts
+recovering can still wait for the recovery, for as long as ctx allows, see
+Recovery.WaitForRecovery.
+*/
+func (s *PublishSpool) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg Publishing) error {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return ErrSpoolClosed
+	}
+	if s.messages > 0 || s.ch.IsClosed() || s.ch.lifeCycle.State() != StateOpen {
+		// Spool rather than wait for the channel to be recovered, the
+		// replay starts once it is open.
+		defer s.kick()
+		defer s.m.Unlock()
+		return s.append(exchange, key, mandatory, immediate, msg)
+	}
+	s.m.Unlock()
+
+	dc, err := s.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if err == nil && s.confirmed(ctx, dc) {
+		return nil
+	}
+
+	s.m.Lock()
+	defer s.kick()
+	defer s.m.Unlock()
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	return s.append(exchange, key, mandatory, immediate, msg)
+}
+
+// Backlog returns the publishings waiting in the spool.
+func (s *PublishSpool) Backlog() SpoolBacklog {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return SpoolBacklog{
+		Messages: s.messages,
+		Bytes:    s.bytes,
+		Segments: len(s.segments),
+	}
+}
+
+// Close stops replaying and closes the spool's files.  Publishings still in
+// the spool are replayed when a spool is opened again in the same directory.
+// The channel is not closed.
+func (s *PublishSpool) Close() error {
+	s.m.Lock()
+	if s.closed {
+		s.m.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.m.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.config.Sync != SpoolSyncNever {
+		if err := s.w.Sync(); err != nil {
+			_ = s.w.Close()
+			return err
+		}
+	}
+	return s.w.Close()
+}
+
+// confirmed waits for the confirmation of a publishing.
+func (s *PublishSpool) confirmed(ctx context.Context, dc *DeferredConfirmation) bool {
+	if dc == nil {
+		// The channel left confirm mode, nothing to wait for.
+		return true
+	}
+
+	if s.config.ConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.ConfirmTimeout)
+		defer cancel()
+	}
+
+	acked, err := dc.WaitContext(ctx)
+	return err == nil && acked
+}
+
+func (s *PublishSpool) kick() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// watch triggers a replay whenever the channel opens.
+func (s *PublishSpool) watch(states <-chan *StateChanged) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case sc, ok := <-states:
+			if !ok {
+				return
+			}
+			if sc.To == StateOpen {
+				s.kick()
+			}
+		}
+	}
+}
+
+func (s *PublishSpool) replayLoop() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.trigger:
+		}
+
+		if err := s.replay(); err != nil && s.ctx.Err() == nil {
+			Logger.Printf("publish spool replay interrupted, %d publishings waiting: %+v", s.Backlog().Messages, err)
+			time.AfterFunc(s.config.RetryInterval, s.kick)
+		}
+	}
+}
+
+// replay publishes spooled records in order until the spool is empty or a
+// publishing fails.
+func (s *PublishSpool) replay() error {
+	for {
+		if s.ch.IsClosed() || s.ch.lifeCycle.State() != StateOpen {
+			// Replay resumes on the transition to StateOpen.
+			return nil
+		}
+
+		s.m.Lock()
+		if s.closed || s.messages == 0 {
+			s.m.Unlock()
+			return nil
+		}
+		seg, off := s.readSeg, s.readOff
+		s.m.Unlock()
+
+		rec, size, err := s.readRecord(seg, off)
+		if errors.Is(err, io.EOF) {
+			s.m.Lock()
+			err = s.nextSegment()
+			s.m.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		dc, err := s.ch.PublishWithDeferredConfirmWithContext(s.ctx, rec.exchange, rec.key, rec.mandatory, rec.immediate, rec.msg)
+		if err != nil {
+			return err
+		}
+		if !s.confirmed(s.ctx, dc) {
+			return errors.New("amqp: spooled publishing was not confirmed")
+		}
+
+		s.m.Lock()
+		err = s.advance(size)
+		s.m.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// open loads the segments and checkpoint from the spool directory, discarding
+// an incomplete record at the end of the last segment.
+func (s *PublishSpool) open() error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, id)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	if err := s.readCheckpoint(); err != nil {
+		return err
+	}
+
+	// Drop segments replayed before the checkpoint was written.
+	for len(s.segments) > 0 && s.segments[0] < s.readSeg {
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		s.segments = s.segments[1:]
+	}
+
+	if len(s.segments) == 0 || s.segments[0] != s.readSeg {
+		s.readOff = 0
+		if len(s.segments) > 0 {
+			s.readSeg = s.segments[0]
+		}
+	}
+
+	for i, id := range s.segments {
+		off := int64(0)
+		if id == s.readSeg {
+			off = s.readOff
+		}
+		end, err := s.scan(id, off)
+		if err != nil {
+			if i != len(s.segments)-1 {
+				return fmt.Errorf("amqp: publish spool segment %s is corrupt: %w", s.segmentPath(id), err)
+			}
+			if err := os.Truncate(s.segmentPath(id), end); err != nil {
+				return err
+			}
+			Logger.Printf("publish spool discarded an incomplete record at the end of %s", s.segmentPath(id))
+		}
+	}
+
+	if len(s.segments) == 0 {
+		s.segments = []uint64{s.readSeg}
+	}
+
+	last := s.segments[len(s.segments)-1]
+	w, err := os.OpenFile(s.segmentPath(last), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := w.Stat()
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+	s.w = w
+	s.wSize = info.Size()
+
+	return nil
+}
+
+// scan counts the valid records of a segment from off, returning the offset
+// following the last valid record and an error if a damaged record follows.
+func (s *PublishSpool) scan(id uint64, off int64) (int64, error) {
+	for {
+		_, size, err := s.readRecord(id, off)
+		if errors.Is(err, io.EOF) {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+		s.messages++
+		s.bytes += size
+		off += size
+	}
+}
+
+func (s *PublishSpool) segmentPath(id uint64) string {
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", id, spoolSegmentExt))
+}
+
+func (s *PublishSpool) readCheckpoint() error {
+	s.readSeg, s.readOff = 1, 0
+
+	b, err := os.ReadFile(filepath.Join(s.config.Dir, spoolCheckpoint))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(b) != spoolCheckpointLen {
+		return fmt.Errorf("amqp: publish spool checkpoint has invalid length %d", len(b))
+	}
+
+	s.readSeg = binary.BigEndian.Uint64(b[0:8])
+	s.readOff = int64(binary.BigEndian.Uint64(b[8:16]))
+	return nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file.  The caller must
+// hold s.m.
+func (s *PublishSpool) writeCheckpoint() error {
+	var b [spoolCheckpointLen]byte
+	binary.BigEndian.PutUint64(b[0:8], s.readSeg)
+	binary.BigEndian.PutUint64(b[8:16], uint64(s.readOff))
+
+	path := filepath.Join(s.config.Dir, spoolCheckpoint)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b[:]); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if s.config.Sync == SpoolSyncAlways {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// append writes a publishing to the last segment.  The caller must hold s.m.
+func (s *PublishSpool) append(exchange, key string, mandatory, immediate bool, msg Publishing) error {
+	payload, err := encodeSpoolRecord(exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) > spoolMaxRecordSize {
+		return ErrMessageTooLarge
+	}
+
+	size := int64(spoolRecordHeader + len(payload))
+	if s.config.MaxBytes > 0 && s.bytes+size > s.config.MaxBytes {
+		return ErrSpoolFull
+	}
+
+	if s.wSize > 0 && s.wSize+size > s.config.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	rec := make([]byte, size)
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(payload))
+	copy(rec[spoolRecordHeader:], payload)
+
+	if _, err := s.w.Write(rec); err != nil {
+		// Cut off whatever part of the record made it to the file.
+		_ = s.w.Truncate(s.wSize)
+		return err
+	}
+	s.wSize += size
+	s.messages++
+	s.bytes += size
+
+	switch s.config.Sync {
+	case SpoolSyncAlways:
+		return s.w.Sync()
+	case SpoolSyncInterval:
+		if time.Since(s.lastSync) >= s.config.SyncInterval {
+			s.lastSync = time.Now()
+			return s.w.Sync()
+		}
+	}
+	return nil
+}
+
+// rotate starts a new segment.  The caller must hold s.m.
+func (s *PublishSpool) rotate() error {
+	if s.config.Sync != SpoolSyncNever {
+		if err := s.w.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := s.w.Close(); err != nil {
+		return err
+	}
+
+	id := s.segments[len(s.segments)-1] + 1
+	w, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	s.w = w
+	s.wSize = 0
+	s.segments = append(s.segments, id)
+	return nil
+}
+
+// advance moves the checkpoint past a replayed record.  The caller must hold
+// s.m.
+func (s *PublishSpool) advance(size int64) error {
+	s.readOff += size
+	s.messages--
+	s.bytes -= size
+	return s.writeCheckpoint()
+}
+
+// nextSegment moves the checkpoint to the segment following a fully replayed
+// one, deleting it.  The caller must hold s.m.
+func (s *PublishSpool) nextSegment() error {
+	if len(s.segments) < 2 || s.segments[0] != s.readSeg {
+		return fmt.Errorf("amqp: publish spool has %d publishings waiting past the end of segment %d", s.messages, s.readSeg)
+	}
+
+	done := s.segments[0]
+	s.segments = s.segments[1:]
+	s.readSeg, s.readOff = s.segments[0], 0
+	if err := s.writeCheckpoint(); err != nil {
+		return err
+	}
+	return os.Remove(s.segmentPath(done))
+}
+
+type spoolRecord struct {
+	exchange  string
+	key       string
+	mandatory bool
+	immediate bool
+	msg       Publishing
+}
+
+// readRecord reads the record at off in a segment, returning io.EOF when off
+// is the end of the segment.
+func (s *PublishSpool) readRecord(id uint64, off int64) (rec spoolRecord, size int64, err error) {
+	f, err := os.Open(s.segmentPath(id))
+	if err != nil {
+		return rec, 0, err
+	}
+	defer f.Close()
+
+	var header [spoolRecordHeader]byte
+	n, err := f.ReadAt(header[:], off)
+	if n == 0 && errors.Is(err, io.EOF) {
+		return rec, 0, io.EOF
+	}
+	if n < spoolRecordHeader {
+		return rec, 0, io.ErrUnexpectedEOF
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	info, err := f.Stat()
+	if err != nil {
+		return rec, 0, err
+	}
+	if length > spoolMaxRecordSize || off+spoolRecordHeader+length > info.Size() {
+		// A torn or corrupt length: the record cannot be whole.
+		return rec, 0, io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, off+spoolRecordHeader); err != nil {
+		return rec, 0, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return rec, 0, errors.New("amqp: publish spool record checksum mismatch")
+	}
+
+	rec, err = decodeSpoolRecord(payload)
+	return rec, int64(spoolRecordHeader + len(payload)), err
+}
+
+// encodeSpoolRecord encodes a publishing as its method, header and body
+// frames.
+func encodeSpoolRecord(exchange, key string, mandatory, immediate bool, msg Publishing) ([]byte, error) {
+	pub := &basicPublish{
+		Exchange:   exchange,
+		RoutingKey: key,
+		Mandatory:  mandatory,
+		Immediate:  immediate,
+	}
+	class, _ := pub.id()
+
+	var buf bytes.Buffer
+	w := &writer{&buf}
+	frames := []frame{
+		&methodFrame{Method: pub},
+		&headerFrame{ClassId: class, Size: uint64(len(msg.Body)), Properties: msg.properties()},
+		&bodyFrame{Body: msg.Body},
+	}
+	for _, f := range frames {
+		if err := w.WriteFrameNoFlush(f); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSpoolRecord(payload []byte) (rec spoolRecord, err error) {
+	r := &reader{r: bytes.NewReader(payload)}
+
+	f, err := r.ReadFrame()
+	if err != nil {
+		return rec, err
+	}
+	mf, ok := f.(*methodFrame)
+	if !ok {
+		return rec, ErrUnexpectedFrame
+	}
+	pub, ok := mf.Method.(*basicPublish)
+	if !ok {
+		return rec, ErrUnexpectedFrame
+	}
+
+	if f, err = r.ReadFrame(); err != nil {
+		return rec, err
+	}
+	header, ok := f.(*headerFrame)
+	if !ok {
+		return rec, ErrUnexpectedFrame
+	}
+
+	if f, err = r.ReadFrame(); err != nil {
+		return rec, err
+	}
+	body, ok := f.(*bodyFrame)
+	if !ok {
+		return rec, ErrUnexpectedFrame
+	}
+
+	props := header.Properties
+	return spoolRecord{
+		exchange:  pub.Exchange,
+		key:       pub.RoutingKey,
+		mandatory: pub.Mandatory,
+		immediate: pub.Immediate,
+		msg: Publishing{
+			Headers:         props.Headers,
+			ContentType:     props.ContentType,
+			ContentEncoding: props.ContentEncoding,
+			DeliveryMode:    props.DeliveryMode,
+			Priority:        props.Priority,
+			CorrelationId:   props.CorrelationId,
+			ReplyTo:         props.ReplyTo,
+			Expiration:      props.Expiration,
+			MessageId:       props.MessageId,
+			Timestamp:       props.Timestamp,
+			Type:            props.Type,
+			UserId:          props.UserId,
+			AppId:           props.AppId,
+			Body:            body.Body,
+		},
+	}, nil
+}
@@ -0,0 +1,404 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestSpool opens the files of a spool without a channel.
+func openTestSpool(t *testing.T, config SpoolConfig) *PublishSpool {
+	t.Helper()
+
+	if config.SegmentSize == 0 {
+		config.SegmentSize = DefaultSpoolSegmentSize
+	}
+	s := &PublishSpool{config: config}
+	if err := s.open(); err != nil {
+		t.Fatalf("could not open spool: %v", err)
+	}
+	t.Cleanup(func() { s.w.Close() })
+	return s
+}
+
+func TestPublishSpoolRecordRoundTrip(t *testing.T) {
+	s := openTestSpool(t, SpoolConfig{Dir: t.TempDir()})
+
+	msg := Publishing{
+		Headers:       Table{"k": "v"},
+		ContentType:   "text/plain",
+		DeliveryMode:  Persistent,
+		CorrelationId: "c-1",
+		Body:          []byte("hello"),
+	}
+	if err := s.append("ex", "key", true, false, msg); err != nil {
+		t.Fatalf("could not append: %v", err)
+	}
+
+	rec, size, err := s.readRecord(s.readSeg, s.readOff)
+	if err != nil {
+		t.Fatalf("could not read record: %v", err)
+	}
+	if size != s.bytes {
+		t.Errorf("expected record size %d, got %d", s.bytes, size)
+	}
+	if rec.exchange != "ex" || rec.key != "key" || !rec.mandatory || rec.immediate {
+		t.Errorf("unexpected publish arguments: %+v", rec)
+	}
+	if rec.msg.ContentType != "text/plain" || rec.msg.DeliveryMode != Persistent ||
+		rec.msg.CorrelationId != "c-1" || rec.msg.Headers["k"] != "v" || string(rec.msg.Body) != "hello" {
+		t.Errorf("unexpected publishing: %+v", rec.msg)
+	}
+}
+
+func TestPublishSpoolMaxBytes(t *testing.T) {
+	s := openTestSpool(t, SpoolConfig{Dir: t.TempDir(), MaxBytes: 200})
+
+	body := make([]byte, 100)
+	if err := s.append("", "q", false, false, Publishing{Body: body}); err != nil {
+		t.Fatalf("could not append: %v", err)
+	}
+	if err := s.append("", "q", false, false, Publishing{Body: body}); !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("expected ErrSpoolFull, got: %v", err)
+	}
+	if s.messages != 1 {
+		t.Errorf("expected the rejected publishing not to be counted, got %d messages", s.messages)
+	}
+}
+
+func TestPublishSpoolSegments(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, SpoolConfig{Dir: dir, SegmentSize: 150})
+
+	for i := 0; i < 3; i++ {
+		if err := s.append("", "q", false, false, Publishing{Body: make([]byte, 100)}); err != nil {
+			t.Fatalf("could not append: %v", err)
+		}
+	}
+	if len(s.segments) != 3 {
+		t.Fatalf("expected a segment per record, got %d segments", len(s.segments))
+	}
+
+	// Replay the first record and move to the next segment.
+	_, size, err := s.readRecord(s.readSeg, s.readOff)
+	if err != nil {
+		t.Fatalf("could not read record: %v", err)
+	}
+	if err := s.advance(size); err != nil {
+		t.Fatalf("could not advance: %v", err)
+	}
+	if _, _, err := s.readRecord(s.readSeg, s.readOff); err == nil {
+		t.Fatal("expected the end of the first segment")
+	}
+	first := s.segmentPath(s.readSeg)
+	if err := s.nextSegment(); err != nil {
+		t.Fatalf("could not move to the next segment: %v", err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("expected the replayed segment to be deleted, got: %v", err)
+	}
+
+	s.w.Close()
+	reopened := openTestSpool(t, SpoolConfig{Dir: dir, SegmentSize: 150})
+	if reopened.messages != 2 || len(reopened.segments) != 2 {
+		t.Errorf("expected 2 messages in 2 segments after reopening, got %d in %d", reopened.messages, len(reopened.segments))
+	}
+}
+
+func TestPublishSpoolTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, SpoolConfig{Dir: dir})
+
+	for i := 0; i < 2; i++ {
+		if err := s.append("", "q", false, false, Publishing{Body: []byte("whole")}); err != nil {
+			t.Fatalf("could not append: %v", err)
+		}
+	}
+	whole := s.wSize
+
+	// Simulate a crash in the middle of writing a record.
+	if _, err := s.w.Write([]byte{0, 0, 1, 0, 1, 2, 3}); err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+	s.w.Close()
+
+	reopened := openTestSpool(t, SpoolConfig{Dir: dir})
+	if reopened.messages != 2 {
+		t.Errorf("expected the 2 whole records to be kept, got %d", reopened.messages)
+	}
+	if reopened.wSize != whole {
+		t.Errorf("expected the segment to be truncated to %d bytes, got %d", whole, reopened.wSize)
+	}
+}
+
+func TestPublishSpoolTruncatesRecordWithCorruptLength(t *testing.T) {
+	for name, length := range map[string][]byte{
+		"oversized":     {0xff, 0xff, 0xff, 0xff},
+		"past file end": {0, 0, 1, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openTestSpool(t, SpoolConfig{Dir: dir})
+
+			if err := s.append("", "q", false, false, Publishing{Body: []byte("whole")}); err != nil {
+				t.Fatalf("could not append: %v", err)
+			}
+			whole := s.wSize
+
+			// A whole header whose length runs past the end of the file.
+			if _, err := s.w.Write(append(length, 0, 0, 0, 0, 1, 2, 3)); err != nil {
+				t.Fatalf("could not write: %v", err)
+			}
+			if _, _, err := s.readRecord(s.readSeg, whole); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("expected io.ErrUnexpectedEOF, got: %v", err)
+			}
+			s.w.Close()
+
+			reopened := openTestSpool(t, SpoolConfig{Dir: dir})
+			if reopened.messages != 1 || reopened.wSize != whole {
+				t.Errorf("expected the segment to be truncated to its whole record, got %d messages in %d bytes", reopened.messages, reopened.wSize)
+			}
+		})
+	}
+}
+
+func TestPublishSpoolReplaysNackedPublishing(t *testing.T) {
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	published := make(chan *basicPublish, 3)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+		srv.send(1, &basicNack{DeliveryTag: 1})
+
+		// The replay of the spooled publishing.
+		pub = &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+		srv.send(1, &basicAck{DeliveryTag: 2})
+
+		pub = &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+		srv.send(1, &basicAck{DeliveryTag: 3})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	s, err := NewPublishSpool(ch, SpoolConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Publish(context.TODO(), "", "q", false, false, Publishing{Body: []byte("first")}); err != nil {
+		t.Fatalf("expected the nacked publishing to be spooled, got: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for s.Backlog().Messages != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the spooled publishing to be replayed, backlog: %+v", s.Backlog())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := s.Publish(context.TODO(), "", "q", false, false, Publishing{Body: []byte("second")}); err != nil {
+		t.Fatalf("unexpected publish error: %v", err)
+	}
+
+	for i, want := range []string{"first", "first", "second"} {
+		if pub := <-published; string(pub.Body) != want {
+			t.Errorf("publishing %d: expected body %q, got %q", i, want, pub.Body)
+		}
+	}
+	<-done
+
+	if backlog := s.Backlog(); backlog.Messages != 0 || backlog.Bytes != 0 || backlog.Segments != 1 {
+		t.Errorf("expected an empty backlog, got: %+v", backlog)
+	}
+}
+
+func TestPublishSpoolSpoolsWhileClosed(t *testing.T) {
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+
+		srv.send(1, &channelClose{ReplyCode: NotFound, ReplyText: "NOT_FOUND"})
+		srv.recv(1, &channelCloseOk{})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+	closed := ch.NotifyClose(make(chan *Error, 1))
+
+	dir := t.TempDir()
+	s, err := NewPublishSpool(ch, SpoolConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+	<-closed
+
+	for _, body := range []string{"a", "b"} {
+		if err := s.Publish(context.TODO(), "", "q", false, false, Publishing{Body: []byte(body)}); err != nil {
+			t.Fatalf("expected the publishing to be spooled, got: %v", err)
+		}
+	}
+	if backlog := s.Backlog(); backlog.Messages != 2 {
+		t.Errorf("expected 2 spooled publishings, got: %+v", backlog)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if err := s.Publish(context.TODO(), "", "q", false, false, Publishing{}); !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("expected ErrSpoolClosed after closing, got: %v", err)
+	}
+
+	reopened := openTestSpool(t, SpoolConfig{Dir: dir})
+	for _, want := range []string{"a", "b"} {
+		rec, size, err := reopened.readRecord(reopened.readSeg, reopened.readOff)
+		if err != nil {
+			t.Fatalf("could not read record: %v", err)
+		}
+		if string(rec.msg.Body) != want {
+			t.Errorf("expected body %q, got %q", want, rec.msg.Body)
+		}
+		reopened.readOff += size
+	}
+}
+
+func TestPublishSpoolIsNotLockedWhilePublishing(t *testing.T) {
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+
+		srv.send(1, &channelFlow{Active: false})
+		srv.recv(1, &channelFlowOk{})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+	flows := ch.NotifyFlow(make(chan bool, 1))
+
+	s, err := NewPublishSpool(ch, SpoolConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+	<-flows
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	published := make(chan error, 1)
+	go func() {
+		published <- s.Publish(ctx, "", "q", false, false, Publishing{Body: []byte("paused")})
+	}()
+
+	closed := make(chan error, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		if backlog := s.Backlog(); backlog.Messages != 0 {
+			t.Errorf("expected an empty backlog, got: %+v", backlog)
+		}
+		closed <- s.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("unexpected close error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Backlog and Close not to wait for a publishing held by the flow control")
+	}
+
+	cancel()
+	if err := <-published; !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("expected ErrSpoolClosed once the spool is closed, got: %v", err)
+	}
+}
+
+func TestPublishSpoolSpoolsWhileRecovering(t *testing.T) {
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &confirmSelect{})
+		srv.send(1, &confirmSelectOk{})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	s, err := NewPublishSpool(ch, SpoolConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+	defer s.Close()
+
+	ch.lifeCycle.SetState(StateReconnecting, nil)
+
+	if err := s.Publish(context.TODO(), "", "q", false, false, Publishing{Body: []byte("a")}); err != nil {
+		t.Fatalf("expected the publishing to be spooled, got: %v", err)
+	}
+	if backlog := s.Backlog(); backlog.Messages != 1 {
+		t.Errorf("expected the publishing to be spooled while the channel recovers, got: %+v", backlog)
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Stream consume arguments and headers.
+const (
+	// StreamOffsetArg is the consume argument telling where to start reading
+	// a stream.  It is also the header holding the offset of each delivery
+	// from a stream.
+	StreamOffsetArg = "x-stream-offset"
+	// StreamFilterArg is the consume argument listing the filter values of
+	// the messages to deliver.
+	StreamFilterArg = "x-stream-filter"
+	// StreamMatchUnfilteredArg is the consume argument to also deliver the
+	// messages published without a filter value.
+	StreamMatchUnfilteredArg = "x-stream-match-unfiltered"
+	// StreamFilterValueHeader is the header holding the filter value of a
+	// message published to a stream.
+	StreamFilterValueHeader = "x-stream-filter-value"
+)
+
+// StreamOffset tells where to start reading a stream.  The zero value starts
+// with the next message published, as StreamOffsetNext does.
+type StreamOffset struct {
+	kind   streamOffsetKind
+	offset int64
+	time   time.Time
+}
+
+type streamOffsetKind int
+
+const (
+	streamOffsetNext streamOffsetKind = iota
+	streamOffsetFirst
+	streamOffsetLast
+	streamOffsetAt
+	streamOffsetTimestamp
+)
+
+var (
+	// StreamOffsetFirst starts with the first message available in the
+	// stream.
+	StreamOffsetFirst = StreamOffset{kind: streamOffsetFirst}
+	// StreamOffsetLast starts with the last chunk of messages written to
+	// the stream.
+	StreamOffsetLast = StreamOffset{kind: streamOffsetLast}
+	// StreamOffsetNext starts with the next message published.
+	StreamOffsetNext = StreamOffset{kind: streamOffsetNext}
+)
+
+// StreamOffsetAt starts with the message at offset.
+func StreamOffsetAt(offset int64) StreamOffset {
+	return StreamOffset{kind: streamOffsetAt, offset: offset}
+}
+
+// StreamOffsetTimestamp starts with the messages written at or after t, with
+// a precision of a second.
+func StreamOffsetTimestamp(t time.Time) StreamOffset {
+	return StreamOffset{kind: streamOffsetTimestamp, time: t}
+}
+
+// arg returns the value of the StreamOffsetArg consume argument.
+func (o StreamOffset) arg() any {
+	switch o.kind {
+	case streamOffsetFirst:
+		return "first"
+	case streamOffsetLast:
+		return "last"
+	case streamOffsetAt:
+		return o.offset
+	case streamOffsetTimestamp:
+		return o.time
+	}
+	return "next"
+}
+
+// StreamOffset returns the offset of a delivery from a stream, which is
+// false for deliveries from other queue types.
+func (d Delivery) StreamOffset() (offset int64, ok bool) {
+	return headerInt(d.Headers[StreamOffsetArg])
+}
+
+// OffsetStore persists the offset processed last by stream consumers, so that
+// they resume after it when restarted.
+type OffsetStore interface {
+	// Load returns the offset stored for the consumer of the stream, and
+	// false when none was stored.
+	Load(ctx context.Context, stream, consumer string) (offset int64, ok bool, err error)
+	// Store records the offset processed last by the consumer of the stream.
+	Store(ctx context.Context, stream, consumer string, offset int64) error
+}
+
+// MemoryOffsetStore is an OffsetStore keeping offsets in memory, which
+// survives recoveries but not restarts of the application.
+type MemoryOffsetStore struct {
+	m       sync.Mutex
+	offsets map[[2]string]int64
+}
+
+// NewMemoryOffsetStore returns an empty MemoryOffsetStore.
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{offsets: make(map[[2]string]int64)}
+}
+
+// Load implements OffsetStore.
+func (s *MemoryOffsetStore) Load(_ context.Context, stream, consumer string) (int64, bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	offset, ok := s.offsets[[2]string{stream, consumer}]
+	return offset, ok, nil
+}
+
+// Store implements OffsetStore.
+func (s *MemoryOffsetStore) Store(_ context.Context, stream, consumer string, offset int64) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.offsets[[2]string{stream, consumer}] = offset
+	return nil
+}
+
+// DefaultStreamPrefetch is the default StreamConsumer.Prefetch.
+const DefaultStreamPrefetch = 100
+
+/*
+StreamConsumer reads a RabbitMQ stream, keeping track of the offset processed
+last.
+
+A delivery is processed once it is acknowledged: Delivery.Ack records the
+offset processed last and saves it to Store, if set.  That offset is the
+highest acknowledged one with no unacknowledged delivery before it, so that
+deliveries acknowledged out of order, for example by concurrent workers, are
+delivered again rather than skipped when the consumer resumes.  Nacked and
+rejected deliveries count as processed, as streams do not redeliver them.  When started again with the same
+Store, the consumer resumes after the offset stored for its name, and starts
+from Offset otherwise.  When the channel is recovered, the consumer subscribes
+again after the offset processed last rather than from Offset.
+
+Deliveries from a stream must be acknowledged, although acknowledging them
+does not remove them from the stream.
+
+Set the exported fields before calling Consume.
+*/
+type StreamConsumer struct {
+	ch     *Channel
+	stream string
+	name   string
+
+	// Offset is where to start when no offset was stored for the consumer.
+	Offset StreamOffset
+
+	// Filters only delivers the messages with one of these filter values,
+	// see StreamFilterValueHeader.  MatchUnfiltered also delivers the
+	// messages without a filter value.
+	Filters         []string
+	MatchUnfiltered bool
+
+	// Store saves the offset processed last.  When nil, the offset is only
+	// tracked in memory to subscribe again on recovery.
+	Store OffsetStore
+
+	// Prefetch is the prefetch count set on the channel, which streams
+	// require.  It defaults to DefaultStreamPrefetch.
+	Prefetch int
+
+	// Args are extra consume arguments.
+	Args Table
+
+	m           sync.Mutex
+	processed   int64
+	tracked     bool
+	outstanding map[int64]struct{} // offsets delivered and not settled yet
+	settled     map[int64]struct{} // offsets settled after an outstanding one
+	storeM      sync.Mutex         // serializes stores so offsets are stored in order
+}
+
+// NewStreamConsumer returns a StreamConsumer reading stream on ch.  name
+// identifies the consumer in the OffsetStore.
+func NewStreamConsumer(ch *Channel, stream, name string) *StreamConsumer {
+	return &StreamConsumer{ch: ch, stream: stream, name: name}
+}
+
+// LastOffset returns the offset processed last, and false when no delivery was
+// processed yet.
+func (c *StreamConsumer) LastOffset() (offset int64, ok bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.processed, c.tracked
+}
+
+/*
+Consume sets the channel's prefetch count and subscribes to the stream, from
+the offset following the one stored for the consumer, or from Offset.  The
+deliveries are returned as by Channel.ConsumeWithContext, which cancels the
+subscription when ctx is done, and are closed then.
+*/
+func (c *StreamConsumer) Consume(ctx context.Context) (<-chan Delivery, error) {
+	start := c.Offset.arg()
+	if c.Store != nil {
+		offset, ok, err := c.Store.Load(ctx, c.stream, c.name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			c.track(offset)
+			start = offset + 1
+		}
+	}
+
+	prefetch := c.Prefetch
+	if prefetch <= 0 {
+		prefetch = DefaultStreamPrefetch
+	}
+	if err := c.ch.Qos(prefetch, 0, false); err != nil {
+		return nil, err
+	}
+
+	in, err := c.ch.consume(ctx, consumerConfig{
+		Queue:        c.stream,
+		Args:         c.args(start),
+		RecoveryArgs: c.recoveryArgs(start),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Delivery)
+	go func() {
+		defer func() {
+			close(out)
+			// Discard deliveries buffered until the cancellation by
+			// ConsumeWithContext closes in.
+			for range in {
+			}
+		}()
+		for d := range in {
+			if offset, ok := d.StreamOffset(); ok && d.Acknowledger != nil {
+				d.Acknowledger = &streamAcknowledger{delivery: d, c: c, offset: offset}
+				c.delivered(offset)
+			}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *StreamConsumer) args(start any) Table {
+	args := make(Table, len(c.Args)+3)
+	for k, v := range c.Args {
+		args[k] = v
+	}
+	args[StreamOffsetArg] = start
+	if len(c.Filters) > 0 {
+		filters := make([]any, len(c.Filters))
+		for i, f := range c.Filters {
+			filters[i] = f
+		}
+		args[StreamFilterArg] = filters
+		if c.MatchUnfiltered {
+			args[StreamMatchUnfilteredArg] = true
+		}
+	}
+	return args
+}
+
+// recoveryArgs subscribes again after the offset processed last, or from
+// start when none was processed.
+func (c *StreamConsumer) recoveryArgs(start any) func() Table {
+	return func() Table {
+		if offset, ok := c.LastOffset(); ok {
+			return c.args(offset + 1)
+		}
+		return c.args(start)
+	}
+}
+
+// track records offset as processed, unless a later offset already was.
+func (c *StreamConsumer) track(offset int64) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.tracked && offset <= c.processed {
+		return false
+	}
+	c.processed, c.tracked = offset, true
+	return true
+}
+
+// delivered records the offset of a delivery handed to the application, which
+// holds back the offsets settled after it until it is settled too.
+func (c *StreamConsumer) delivered(offset int64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.outstanding == nil {
+		c.outstanding = make(map[int64]struct{})
+		c.settled = make(map[int64]struct{})
+	}
+	c.outstanding[offset] = struct{}{}
+}
+
+// settle records the delivery at offset, and with multiple the earlier ones, as
+// settled, and tracks the highest settled offset below every outstanding one.
+// It reports whether the offset processed last moved.
+func (c *StreamConsumer) settle(offset int64, multiple bool) bool {
+	c.m.Lock()
+	lowest := int64(math.MaxInt64)
+	for o := range c.outstanding {
+		if o == offset || multiple && o < offset {
+			delete(c.outstanding, o)
+			c.settled[o] = struct{}{}
+		} else if o < lowest {
+			lowest = o
+		}
+	}
+	c.settled[offset] = struct{}{}
+
+	var (
+		done  int64
+		found bool
+	)
+	for o := range c.settled {
+		if o < lowest {
+			if !found || o > done {
+				done, found = o, true
+			}
+			delete(c.settled, o)
+		}
+	}
+	c.m.Unlock()
+
+	return found && c.track(done)
+}
+
+// store saves the offset processed last.
+func (c *StreamConsumer) store() {
+	c.storeM.Lock()
+	defer c.storeM.Unlock()
+
+	offset, _ := c.LastOffset()
+	if err := c.Store.Store(context.Background(), c.stream, c.name, offset); err != nil {
+		Logger.Printf("error storing offset %d of stream %s for consumer %s: %+v", offset, c.stream, c.name, err)
+	}
+}
+
+// streamAcknowledger tracks the offset of a delivery from a stream once it
+// is acknowledged.  It settles the delivery as received, so that it is checked
+// not to be stale, see Delivery.Stale.
+type streamAcknowledger struct {
+	delivery Delivery
+	c        *StreamConsumer
+	offset   int64
+}
+
+func (a *streamAcknowledger) Ack(tag uint64, multiple bool) error {
+	d := a.delivery
+	d.DeliveryTag = tag
+	if err := d.Ack(multiple); err != nil {
+		return err
+	}
+	a.settled(multiple)
+	return nil
+}
+
+func (a *streamAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	d := a.delivery
+	d.DeliveryTag = tag
+	if err := d.Nack(multiple, requeue); err != nil {
+		return err
+	}
+	a.settled(multiple)
+	return nil
+}
+
+func (a *streamAcknowledger) Reject(tag uint64, requeue bool) error {
+	d := a.delivery
+	d.DeliveryTag = tag
+	if err := d.Reject(requeue); err != nil {
+		return err
+	}
+	a.settled(false)
+	return nil
+}
+
+func (a *streamAcknowledger) settled(multiple bool) {
+	if a.c.settle(a.offset, multiple) && a.c.Store != nil {
+		a.c.store()
+	}
+}
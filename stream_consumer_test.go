@@ -0,0 +1,215 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamOffsetArg(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		offset StreamOffset
+		want   any
+	}{
+		{StreamOffset{}, "next"},
+		{StreamOffsetNext, "next"},
+		{StreamOffsetFirst, "first"},
+		{StreamOffsetLast, "last"},
+		{StreamOffsetAt(0), int64(0)},
+		{StreamOffsetAt(42), int64(42)},
+		{StreamOffsetTimestamp(at), at},
+	}
+	for _, tt := range tests {
+		if got := tt.offset.arg(); got != tt.want {
+			t.Errorf("expected %#v, got %#v", tt.want, got)
+		}
+	}
+}
+
+func TestStreamConsumerResumesFromStoredOffset(t *testing.T) {
+	type served struct {
+		qos     *basicQos
+		consume *basicConsume
+		acks    []*basicAck
+	}
+	results := make(chan served, 1)
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		var r served
+
+		r.qos = &basicQos{}
+		srv.recv(1, r.qos)
+		srv.send(1, &basicQosOk{})
+
+		r.consume = &basicConsume{}
+		srv.recv(1, r.consume)
+		srv.send(1, &basicConsumeOk{ConsumerTag: r.consume.ConsumerTag})
+
+		for i, offset := range []int64{42, 43} {
+			deliver := &basicDeliver{ConsumerTag: r.consume.ConsumerTag, DeliveryTag: uint64(i + 1)}
+			deliver.Properties.Headers = Table{StreamOffsetArg: offset}
+			srv.send(1, deliver)
+		}
+		for i := 0; i < 2; i++ {
+			ack := &basicAck{}
+			srv.recv(1, ack)
+			r.acks = append(r.acks, ack)
+		}
+
+		results <- r
+	})
+
+	store := NewMemoryOffsetStore()
+	if err := store.Store(context.TODO(), "events", "reader", 41); err != nil {
+		t.Fatalf("could not store offset: %v", err)
+	}
+
+	c := NewStreamConsumer(ch, "events", "reader")
+	c.Offset = StreamOffsetFirst
+	c.Filters = []string{"eu", "us"}
+	c.MatchUnfiltered = true
+	c.Store = store
+
+	deliveries, err := c.Consume(context.TODO())
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	for _, d := range receiveDeliveries(t, deliveries, 2) {
+		offset, ok := d.StreamOffset()
+		if !ok {
+			t.Fatalf("expected the delivery to have a stream offset")
+		}
+		if err := d.Ack(false); err != nil {
+			t.Fatalf("unexpected ack error: %v", err)
+		}
+		if last, _ := c.LastOffset(); last != offset {
+			t.Errorf("expected offset %d to be processed, got %d", offset, last)
+		}
+	}
+
+	r := <-results
+	if r.qos.PrefetchCount != DefaultStreamPrefetch {
+		t.Errorf("expected the default prefetch, got %d", r.qos.PrefetchCount)
+	}
+	wantArgs := Table{
+		StreamOffsetArg:          int64(42),
+		StreamFilterArg:          []any{"eu", "us"},
+		StreamMatchUnfilteredArg: true,
+	}
+	if r.consume.Queue != "events" || !reflect.DeepEqual(r.consume.Arguments, wantArgs) {
+		t.Errorf("expected to resume after the stored offset, got: %+v", r.consume)
+	}
+	if len(r.acks) != 2 || r.acks[1].DeliveryTag != 2 {
+		t.Errorf("expected both deliveries to be acknowledged, got: %+v", r.acks)
+	}
+
+	if offset, ok, _ := store.Load(context.TODO(), "events", "reader"); !ok || offset != 43 {
+		t.Errorf("expected offset 43 to be stored, got %d, %v", offset, ok)
+	}
+
+	// On recovery, the consumer subscribes after the offset processed last.
+	ch.consumers.Lock()
+	defer ch.consumers.Unlock()
+	if len(ch.consumers.configs) != 1 {
+		t.Fatalf("expected a single consumer, got %d", len(ch.consumers.configs))
+	}
+	for _, config := range ch.consumers.configs {
+		if got := config.recoveryArgs()[StreamOffsetArg]; got != int64(44) {
+			t.Errorf("expected to recover from offset 44, got %#v", got)
+		}
+		if got := config.Args[StreamOffsetArg]; got != int64(42) {
+			t.Errorf("expected the original arguments to be kept, got %#v", got)
+		}
+	}
+}
+
+func TestStreamConsumerRecoversFromStartWithoutProcessedOffset(t *testing.T) {
+	c := NewStreamConsumer(nil, "events", "reader")
+	c.Offset = StreamOffsetLast
+
+	recoveryArgs := c.recoveryArgs(c.Offset.arg())
+	if got := recoveryArgs()[StreamOffsetArg]; got != "last" {
+		t.Errorf("expected to recover from the original offset, got %#v", got)
+	}
+
+	c.track(10)
+	c.track(7)
+	if got := recoveryArgs()[StreamOffsetArg]; got != int64(11) {
+		t.Errorf("expected to recover after the latest offset processed, got %#v", got)
+	}
+}
+
+func TestStreamConsumerStoresOnlyContiguousOffsets(t *testing.T) {
+	c := NewStreamConsumer(nil, "events", "reader")
+	for offset := int64(10); offset <= 14; offset++ {
+		c.delivered(offset)
+	}
+
+	steps := []struct {
+		offset   int64
+		multiple bool
+		want     int64
+		tracked  bool
+	}{
+		{12, false, 0, false}, // 10 and 11 are still being processed
+		{10, false, 10, true},
+		{11, false, 12, true},
+		{14, false, 12, true},
+		{13, true, 14, true},
+	}
+	for _, step := range steps {
+		c.settle(step.offset, step.multiple)
+		if offset, ok := c.LastOffset(); offset != step.want || ok != step.tracked {
+			t.Errorf("after settling %d: expected offset %d (%v), got %d (%v)", step.offset, step.want, step.tracked, offset, ok)
+		}
+	}
+}
+
+func TestStreamConsumerStopsForwardingWhenContextDone(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		srv.recv(1, &basicQos{})
+		srv.send(1, &basicQosOk{})
+
+		consume := &basicConsume{}
+		srv.recv(1, consume)
+		srv.send(1, &basicConsumeOk{ConsumerTag: consume.ConsumerTag})
+
+		deliver := &basicDeliver{ConsumerTag: consume.ConsumerTag, DeliveryTag: 1, Body: []byte("unread")}
+		deliver.Properties.Headers = Table{StreamOffsetArg: int64(1)}
+		srv.send(1, deliver)
+
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicCancelOk{ConsumerTag: consume.ConsumerTag})
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deliveries, err := NewStreamConsumer(ch, "events", "reader").Consume(ctx)
+	if err != nil {
+		t.Fatalf("could not consume: %v", err)
+	}
+
+	// Give the delivery time to reach the forwarding goroutine.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-cancelled
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case d, ok := <-deliveries:
+		if ok {
+			t.Errorf("expected the deliveries to be closed once ctx is done, got: %q", d.Body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the deliveries to be closed once ctx is done")
+	}
+}
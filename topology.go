@@ -0,0 +1,334 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidTopology is returned for a Topology that cannot be declared, with
+// details on the invalid entity.
+var ErrInvalidTopology = errors.New("amqp: invalid topology")
+
+func invalidTopology(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidTopology, fmt.Sprintf(format, a...))
+}
+
+// Destination types of a BindingDefinition.
+const (
+	BindingDestinationQueue    = "queue"
+	BindingDestinationExchange = "exchange"
+)
+
+/*
+Topology declares exchanges, queues and bindings.  Its JSON form follows the
+definitions exported by the RabbitMQ management plugin, so that their
+exchanges, queues and bindings can be loaded with LoadTopology, while other
+definitions such as users and policies are ignored.  Policies can be expressed
+as queue arguments instead:
+
+	{
+	  "exchanges": [{"name": "orders", "type": "topic", "durable": true}],
+	  "queues": [{"name": "orders.eu", "type": "quorum", "durable": true,
+	              "arguments": {"x-delivery-limit": 5}}],
+	  "bindings": [{"source": "orders", "destination": "orders.eu",
+	                "destination_type": "queue", "routing_key": "eu.#"}]
+	}
+
+YAML is not supported, as it would add a dependency: convert YAML documents to
+JSON first.
+*/
+type Topology struct {
+	Exchanges []ExchangeDefinition `json:"exchanges,omitempty"`
+	Queues    []QueueDefinition    `json:"queues,omitempty"`
+	Bindings  []BindingDefinition  `json:"bindings,omitempty"`
+}
+
+// ExchangeDefinition declares an exchange, see Channel.ExchangeDeclare.
+type ExchangeDefinition struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"auto_delete"`
+	Internal   bool   `json:"internal"`
+	Arguments  Table  `json:"arguments,omitempty"`
+}
+
+// QueueDefinition declares a queue, see Channel.QueueDeclare.  Type, when
+// set, is passed as the QueueTypeArg argument.
+type QueueDefinition struct {
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"auto_delete"`
+	Exclusive  bool   `json:"exclusive"`
+	Arguments  Table  `json:"arguments,omitempty"`
+}
+
+// BindingDefinition binds a queue or, when DestinationType is
+// BindingDestinationExchange, an exchange to the Source exchange, see
+// Channel.QueueBind and Channel.ExchangeBind.  DestinationType defaults to
+// BindingDestinationQueue.
+type BindingDefinition struct {
+	Source          string `json:"source"`
+	Destination     string `json:"destination"`
+	DestinationType string `json:"destination_type,omitempty"`
+	RoutingKey      string `json:"routing_key"`
+	Arguments       Table  `json:"arguments,omitempty"`
+}
+
+func (b BindingDefinition) toExchange() bool {
+	return b.DestinationType == BindingDestinationExchange
+}
+
+// arguments returns the queue arguments, including its type.
+func (q QueueDefinition) arguments() Table {
+	if q.Type == "" {
+		return q.Arguments
+	}
+	args := make(Table, len(q.Arguments)+1)
+	for k, v := range q.Arguments {
+		args[k] = v
+	}
+	args[QueueTypeArg] = q.Type
+	return args
+}
+
+// LoadTopology reads a Topology from JSON and validates it.  Integral
+// numbers in arguments are read as int64, as expected by the server for
+// arguments such as QueueMessageTTLArg.
+func LoadTopology(r io.Reader) (*Topology, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	t := &Topology{}
+	if err := dec.Decode(t); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTopology, err)
+	}
+
+	for i := range t.Exchanges {
+		t.Exchanges[i].Arguments = jsonTable(t.Exchanges[i].Arguments)
+	}
+	for i := range t.Queues {
+		t.Queues[i].Arguments = jsonTable(t.Queues[i].Arguments)
+	}
+	for i := range t.Bindings {
+		t.Bindings[i].Arguments = jsonTable(t.Bindings[i].Arguments)
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// jsonTable converts the values decoded from JSON to field values.
+func jsonTable(t Table) Table {
+	for k, v := range t {
+		t[k] = jsonValue(v)
+	}
+	return t
+}
+
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		return jsonTable(Table(v))
+	case []any:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+	}
+	return v
+}
+
+// Validate checks that the topology can be declared.
+func (t *Topology) Validate() error {
+	exchanges := make(map[string]bool, len(t.Exchanges))
+	for _, e := range t.Exchanges {
+		switch {
+		case e.Name == "":
+			return invalidTopology("exchange without a name")
+		case exchanges[e.Name]:
+			return invalidTopology("exchange %q defined twice", e.Name)
+		case e.Type == "":
+			return invalidTopology("exchange %q without a type", e.Name)
+		}
+		if err := e.Arguments.Validate(); err != nil {
+			return invalidTopology("exchange %q: %v", e.Name, err)
+		}
+		exchanges[e.Name] = true
+	}
+
+	queues := make(map[string]bool, len(t.Queues))
+	for _, q := range t.Queues {
+		switch {
+		case q.Name == "":
+			return invalidTopology("queue without a name")
+		case queues[q.Name]:
+			return invalidTopology("queue %q defined twice", q.Name)
+		}
+		if kind, ok := q.Arguments[QueueTypeArg]; ok && q.Type != "" && kind != q.Type {
+			return invalidTopology("queue %q of type %q has a %s argument of %v", q.Name, q.Type, QueueTypeArg, kind)
+		}
+		if kind := q.arguments()[QueueTypeArg]; kind == QueueTypeQuorum || kind == QueueTypeStream {
+			if !q.Durable || q.AutoDelete || q.Exclusive {
+				return invalidTopology("queue %q: %s queues must be durable, not auto-deleted and not exclusive", q.Name, kind)
+			}
+		}
+		if err := q.Arguments.Validate(); err != nil {
+			return invalidTopology("queue %q: %v", q.Name, err)
+		}
+		queues[q.Name] = true
+	}
+
+	for _, b := range t.Bindings {
+		switch {
+		case b.Source == "":
+			return invalidTopology("binding of %q to the default exchange", b.Destination)
+		case b.Destination == "":
+			return invalidTopology("binding from %q without a destination", b.Source)
+		case b.DestinationType != "" && b.DestinationType != BindingDestinationQueue && b.DestinationType != BindingDestinationExchange:
+			return invalidTopology("binding from %q to %q: unknown destination type %q", b.Source, b.Destination, b.DestinationType)
+		}
+		if err := b.Arguments.Validate(); err != nil {
+			return invalidTopology("binding from %q to %q: %v", b.Source, b.Destination, err)
+		}
+	}
+
+	return nil
+}
+
+// TopologyChange is a declaration made by Topology.Apply, as reported by
+// Topology.Plan.  Its names follow TopologyRecoveryEntity: bindings are named
+// by their destination, with the source exchange as SecondaryName.
+type TopologyChange struct {
+	EntityType    TopologyRecoveryEntityType
+	EntityName    string
+	SecondaryName string
+	RoutingKey    string
+
+	// Exists is true when the exchange or queue exists already, in which
+	// case declaring it only checks that it matches its definition.  It is
+	// always false for bindings, which cannot be looked up.
+	Exists bool
+}
+
+func (c TopologyChange) String() string {
+	switch c.EntityType {
+	case TopologyEntityQueueBinding, TopologyEntityExchangeBinding:
+		return fmt.Sprintf("bind %s %q to exchange %q with key %q", c.EntityType, c.EntityName, c.SecondaryName, c.RoutingKey)
+	}
+	if c.Exists {
+		return fmt.Sprintf("check %s %q", c.EntityType, c.EntityName)
+	}
+	return fmt.Sprintf("create %s %q", c.EntityType, c.EntityName)
+}
+
+/*
+Plan returns the declarations Topology.Apply would make on ch, in order,
+without declaring anything.  Exchanges and queues are looked up with passive
+declarations, each on a short-lived channel of ch's connection so that the
+server closing it for a missing entity does not affect ch.
+*/
+func (t *Topology) Plan(ch *Channel) ([]TopologyChange, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	changes := make([]TopologyChange, 0, len(t.Exchanges)+len(t.Queues)+len(t.Bindings))
+
+	for _, e := range t.Exchanges {
+		exists, err := ch.connection.exists(func(probe *Channel) error {
+			return probe.ExchangeDeclarePassive(e.Name, e.Type, e.Durable, e.AutoDelete, e.Internal, false, e.Arguments)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("looking up exchange %q: %w", e.Name, err)
+		}
+		changes = append(changes, TopologyChange{EntityType: TopologyEntityExchange, EntityName: e.Name, Exists: exists})
+	}
+
+	for _, q := range t.Queues {
+		exists, err := ch.connection.exists(func(probe *Channel) error {
+			_, err := probe.QueueDeclarePassive(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, nil)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("looking up queue %q: %w", q.Name, err)
+		}
+		changes = append(changes, TopologyChange{EntityType: TopologyEntityQueue, EntityName: q.Name, Exists: exists})
+	}
+
+	for _, b := range t.Bindings {
+		kind := TopologyEntityQueueBinding
+		if b.toExchange() {
+			kind = TopologyEntityExchangeBinding
+		}
+		changes = append(changes, TopologyChange{EntityType: kind, EntityName: b.Destination, SecondaryName: b.Source, RoutingKey: b.RoutingKey})
+	}
+
+	return changes, nil
+}
+
+// exists reports whether the passive declaration made by check succeeds, and
+// false when the server reports the entity is not found.
+func (c *Connection) exists(check func(probe *Channel) error) (bool, error) {
+	err := c.probe(check)
+
+	var amqpErr *Error
+	if errors.As(err, &amqpErr) && amqpErr.Code == NotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+/*
+Apply declares the topology on ch: exchanges first, then queues, then the
+bindings between them.  Declaring is idempotent, so Apply can run on every
+start of the application.  An entity that exists with a different definition
+makes the server close ch, and Apply returns the error along with the entity.
+
+The entities are declared with the methods of ch, so they are recorded for
+topology recovery as when declared one by one, see Channel.TopologyConfiguration.
+*/
+func (t *Topology) Apply(ch *Channel) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	for _, e := range t.Exchanges {
+		if err := ch.ExchangeDeclare(e.Name, e.Type, e.Durable, e.AutoDelete, e.Internal, false, e.Arguments); err != nil {
+			return fmt.Errorf("declaring exchange %q: %w", e.Name, err)
+		}
+	}
+
+	for _, q := range t.Queues {
+		if _, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.arguments()); err != nil {
+			return fmt.Errorf("declaring queue %q: %w", q.Name, err)
+		}
+	}
+
+	for _, b := range t.Bindings {
+		var err error
+		if b.toExchange() {
+			err = ch.ExchangeBind(b.Destination, b.RoutingKey, b.Source, false, b.Arguments)
+		} else {
+			err = ch.QueueBind(b.Destination, b.RoutingKey, b.Source, false, b.Arguments)
+		}
+		if err != nil {
+			return fmt.Errorf("binding %q to exchange %q with key %q: %w", b.Destination, b.Source, b.RoutingKey, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const definitions = `{
+  "rabbit_version": "4.1.0",
+  "users": [{"name": "guest"}],
+  "exchanges": [
+    {"name": "orders", "vhost": "/", "type": "topic", "durable": true, "auto_delete": false, "internal": false, "arguments": {}},
+    {"name": "audit", "vhost": "/", "type": "fanout", "durable": true, "arguments": {"alternate-exchange": "unrouted"}}
+  ],
+  "queues": [
+    {"name": "orders.eu", "vhost": "/", "type": "quorum", "durable": true, "auto_delete": false,
+     "arguments": {"x-delivery-limit": 5, "x-dead-letter-exchange": "audit", "x-custom": [1.5, {"nested": 2}]}}
+  ],
+  "bindings": [
+    {"source": "orders", "vhost": "/", "destination": "orders.eu", "destination_type": "queue", "routing_key": "eu.#", "arguments": {}},
+    {"source": "orders", "vhost": "/", "destination": "audit", "destination_type": "exchange", "routing_key": "#", "arguments": {}}
+  ]
+}`
+
+func TestLoadTopology(t *testing.T) {
+	topology, err := LoadTopology(strings.NewReader(definitions))
+	if err != nil {
+		t.Fatalf("could not load topology: %v", err)
+	}
+
+	want := &Topology{
+		Exchanges: []ExchangeDefinition{
+			{Name: "orders", Type: ExchangeTopic, Durable: true, Arguments: Table{}},
+			{Name: "audit", Type: ExchangeFanout, Durable: true, Arguments: Table{AlternateExchangeArg: "unrouted"}},
+		},
+		Queues: []QueueDefinition{
+			{Name: "orders.eu", Type: QueueTypeQuorum, Durable: true, Arguments: Table{
+				QueueDeliveryLimitArg:      int64(5),
+				QueueDeadLetterExchangeArg: "audit",
+				"x-custom":                 []any{1.5, Table{"nested": int64(2)}},
+			}},
+		},
+		Bindings: []BindingDefinition{
+			{Source: "orders", Destination: "orders.eu", DestinationType: BindingDestinationQueue, RoutingKey: "eu.#", Arguments: Table{}},
+			{Source: "orders", Destination: "audit", DestinationType: BindingDestinationExchange, RoutingKey: "#", Arguments: Table{}},
+		},
+	}
+	if !reflect.DeepEqual(topology, want) {
+		t.Errorf("unexpected topology:\n got: %#v\nwant: %#v", topology, want)
+	}
+
+	for name, doc := range map[string]string{
+		"malformed":           `{"queues": [`,
+		"exchange type":       `{"exchanges": [{"name": "e"}]}`,
+		"duplicate queue":     `{"queues": [{"name": "q"}, {"name": "q"}]}`,
+		"transient quorum":    `{"queues": [{"name": "q", "type": "quorum"}]}`,
+		"conflicting type":    `{"queues": [{"name": "q", "type": "classic", "arguments": {"x-queue-type": "quorum"}}]}`,
+		"default exchange":    `{"bindings": [{"source": "", "destination": "q"}]}`,
+		"unknown destination": `{"bindings": [{"source": "e", "destination": "q", "destination_type": "stream"}]}`,
+	} {
+		if _, err := LoadTopology(strings.NewReader(doc)); !errors.Is(err, ErrInvalidTopology) {
+			t.Errorf("%s: expected ErrInvalidTopology, got: %v", name, err)
+		}
+	}
+}
+
+func TestTopologyPlanLooksUpEntities(t *testing.T) {
+	ch := openServedChannel(t, defaultConfig(), func(srv *server) {
+		// The exchange exists.
+		srv.channelOpen(2)
+		exchange := &exchangeDeclare{}
+		srv.recv(2, exchange)
+		if !exchange.Passive || exchange.Exchange != "orders" {
+			t.Errorf("expected a passive declaration of the exchange, got: %+v", exchange)
+		}
+		srv.send(2, &exchangeDeclareOk{})
+		srv.recv(2, &channelClose{})
+		srv.send(2, &channelCloseOk{})
+
+		// The queue does not.
+		serveMissingQueueProbe(t, srv, 3)
+	})
+
+	topology := &Topology{
+		Exchanges: []ExchangeDefinition{{Name: "orders", Type: ExchangeTopic, Durable: true}},
+		Queues:    []QueueDefinition{{Name: "orders.eu", Durable: true}},
+		Bindings:  []BindingDefinition{{Source: "orders", Destination: "orders.eu", RoutingKey: "eu.#"}},
+	}
+
+	changes, err := topology.Plan(ch)
+	if err != nil {
+		t.Fatalf("could not plan topology: %v", err)
+	}
+
+	want := []string{
+		`check exchange "orders"`,
+		`create queue "orders.eu"`,
+		`bind queue-binding "orders.eu" to exchange "orders" with key "eu.#"`,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got: %v", len(want), changes)
+	}
+	for i, change := range changes {
+		if change.String() != want[i] {
+			t.Errorf("expected change %q, got %q", want[i], change)
+		}
+	}
+
+	if ch.IsClosed() {
+		t.Error("expected the channel to stay open")
+	}
+}
+
+func TestTopologyApplyRecordsEntities(t *testing.T) {
+	rwc, srv := newSession(t)
+	t.Cleanup(func() { rwc.Close() })
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &exchangeDeclare{})
+		srv.send(1, &exchangeDeclareOk{})
+		srv.recv(1, &exchangeDeclare{})
+		srv.send(1, &exchangeDeclareOk{})
+
+		q := &queueDeclare{}
+		srv.recv(1, q)
+		if q.Arguments[QueueTypeArg] != QueueTypeQuorum {
+			t.Errorf("expected the queue type as an argument, got: %+v", q)
+		}
+		srv.send(1, &queueDeclareOk{Queue: q.Queue})
+
+		srv.recv(1, &queueBind{})
+		srv.send(1, &queueBindOk{})
+		srv.recv(1, &exchangeBind{})
+		srv.send(1, &exchangeBindOk{})
+	}()
+
+	// Record the topology for recovery.
+	config := defaultConfig()
+	config.Recovery = &Recovery{
+		ReconnectionConfig: &ReconnectionConfig{MaxRetryCount: 1},
+		ConnectionRecovery: manualRecovery{},
+		TopologyRecovery:   &DefaultTopologyRecovery{},
+	}
+
+	c, err := Open(rwc, config)
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	topology, err := LoadTopology(strings.NewReader(definitions))
+	if err != nil {
+		t.Fatalf("could not load topology: %v", err)
+	}
+	if err := topology.Apply(ch); err != nil {
+		t.Fatalf("could not apply topology: %v", err)
+	}
+
+	recorded := ch.TopologyConfiguration(false)
+	if len(recorded.Exchanges) != 2 || len(recorded.Queues) != 1 || len(recorded.Bindings) != 1 || len(recorded.ExchangeBindings) != 1 {
+		t.Errorf("expected every entity to be recorded for recovery, got: %+v", recorded)
+	}
+	if q := recorded.Queues["orders.eu"]; !q.Durable || q.Args[QueueTypeArg] != QueueTypeQuorum {
+		t.Errorf("unexpected recorded queue: %+v", q)
+	}
+}
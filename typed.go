@@ -0,0 +1,383 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Serializer marshals message values to bodies and back for a content type.
+// Serializers are registered with RegisterSerializer under the ContentType
+// they produce and are used by TypedPublisher and TypedConsumer.
+type Serializer interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ContentTypeJSON is the content type of the built-in JSON serializer, which
+// is also used for deliveries without a ContentType.
+const ContentTypeJSON = "application/json"
+
+var (
+	serializersM sync.RWMutex
+	serializers  = map[string]Serializer{
+		ContentTypeJSON: jsonSerializer{},
+	}
+)
+
+// RegisterSerializer makes a Serializer available for the given content type,
+// replacing any serializer previously registered for it.
+func RegisterSerializer(contentType string, s Serializer) {
+	serializersM.Lock()
+	defer serializersM.Unlock()
+	serializers[contentType] = s
+}
+
+// LookupSerializer returns the Serializer registered for the given content
+// type.  An empty content type selects the JSON serializer.
+func LookupSerializer(contentType string) (Serializer, bool) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+
+	serializersM.RLock()
+	defer serializersM.RUnlock()
+	s, ok := serializers[contentType]
+	return s, ok
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonSerializer) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// TypeRegistry maps message type names, carried in Publishing.Type and
+// Delivery.Type, to Go types.  It lets a single TypedPublisher or
+// TypedConsumer handle several message types, usually through an interface
+// type parameter.  Use RegisterType to add types.
+type TypeRegistry struct {
+	m       sync.RWMutex
+	decoder map[string]func(Serializer, []byte) (any, error)
+	names   map[reflect.Type]string
+}
+
+// NewTypeRegistry returns an empty TypeRegistry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		decoder: make(map[string]func(Serializer, []byte) (any, error)),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// RegisterType registers the type V under the given type name.  Values of
+// type V published with a TypedPublisher using r have their Publishing.Type
+// set to name, and deliveries with that Type are decoded into a V by a
+// TypedConsumer using r.
+func RegisterType[V any](r *TypeRegistry, name string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.decoder[name] = func(s Serializer, data []byte) (any, error) {
+		var v V
+		if err := s.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	r.names[reflect.TypeOf((*V)(nil)).Elem()] = name
+}
+
+// nameOf returns the type name registered for the dynamic type of v.
+func (r *TypeRegistry) nameOf(v any) (string, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	name, ok := r.names[reflect.TypeOf(v)]
+	return name, ok
+}
+
+// decode unmarshals data into a new value of the type registered for name.
+func (r *TypeRegistry) decode(name string, s Serializer, data []byte) (any, error) {
+	r.m.RLock()
+	decode, ok := r.decoder[name]
+	r.m.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("amqp: no type registered for message type %q", name)
+	}
+	return decode(s, data)
+}
+
+// TypedPublisher publishes values of type T, serialized according to the
+// content type of each publishing.
+type TypedPublisher[T any] struct {
+	ch       *Channel
+	exchange string
+	key      string
+
+	// ContentType selects the Serializer for publishings that don't set
+	// their own ContentType.  It defaults to ContentTypeJSON.
+	ContentType string
+
+	// Types, when set, is used to set Publishing.Type from the dynamic type
+	// of the published value.  Publishing a value whose type is not
+	// registered returns an error.
+	Types *TypeRegistry
+}
+
+// NewTypedPublisher returns a TypedPublisher that publishes to the given
+// exchange and routing key on ch.
+func NewTypedPublisher[T any](ch *Channel, exchange, key string) *TypedPublisher[T] {
+	return &TypedPublisher[T]{
+		ch:          ch,
+		exchange:    exchange,
+		key:         key,
+		ContentType: ContentTypeJSON,
+	}
+}
+
+// Publish serializes v into the body of msg and publishes it with
+// Channel.PublishWithDeferredConfirmWithContext.  The properties of msg are
+// sent as they are, except that an empty ContentType is set to the
+// publisher's ContentType and Type is set when the publisher has a
+// TypeRegistry.  The returned DeferredConfirmation is nil unless the channel
+// is in confirm mode.
+func (p *TypedPublisher[T]) Publish(ctx context.Context, v T, msg Publishing) (*DeferredConfirmation, error) {
+	if msg.ContentType == "" {
+		msg.ContentType = p.ContentType
+	}
+
+	if p.Types != nil {
+		name, ok := p.Types.nameOf(v)
+		if !ok {
+			return nil, fmt.Errorf("amqp: no message type registered for %T", v)
+		}
+		msg.Type = name
+	}
+
+	s, ok := LookupSerializer(msg.ContentType)
+	if !ok {
+		return nil, fmt.Errorf("amqp: no serializer registered for content type %q", msg.ContentType)
+	}
+
+	body, err := s.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("amqp: serializing %T as %q: %w", v, msg.ContentType, err)
+	}
+	msg.Body = body
+
+	return p.ch.PublishWithDeferredConfirmWithContext(ctx, p.exchange, p.key, false, false, msg)
+}
+
+// TypedDelivery is a Delivery along with its decoded body.
+type TypedDelivery[T any] struct {
+	Delivery
+	Message T
+}
+
+// DecodeErrorHandler is called by a TypedConsumer with each delivery whose
+// body could not be decoded, and is responsible for settling it when the
+// consumer does not use automatic acknowledgement.  Any function can be used
+// as a callback; RejectOnDecodeError and DeadLetterOnDecodeError provide the
+// common strategies.
+type DecodeErrorHandler func(d Delivery, err error)
+
+// RejectOnDecodeError rejects deliveries that cannot be decoded without
+// requeueing them, so they are dropped or dead-lettered by the server
+// according to the queue's configuration.  It is the default
+// DecodeErrorHandler of a TypedConsumer without automatic acknowledgement.
+func RejectOnDecodeError(d Delivery, err error) {
+	if rerr := d.Reject(false); rerr != nil {
+		Logger.Printf("error rejecting undecodable delivery %d: %+v", d.DeliveryTag, rerr)
+	}
+}
+
+// DecodeErrorHeader is the header set by DeadLetterOnDecodeError to the error
+// that prevented decoding.
+const DecodeErrorHeader = "x-decode-error"
+
+// DeadLetterOnDecodeError returns a DecodeErrorHandler that republishes
+// deliveries that cannot be decoded to exchange with routing key key on ch,
+// with the decode error in the DecodeErrorHeader header, and then acknowledges
+// them.  If republishing fails, the delivery is rejected with requeue so it
+// is not lost.
+func DeadLetterOnDecodeError(ch *Channel, exchange, key string) DecodeErrorHandler {
+	return func(d Delivery, err error) {
+		headers := make(Table, len(d.Headers)+1)
+		for k, v := range d.Headers {
+			headers[k] = v
+		}
+		headers[DecodeErrorHeader] = err.Error()
+
+		perr := ch.PublishWithContext(context.Background(), exchange, key, false, false, Publishing{
+			Headers:         headers,
+			ContentType:     d.ContentType,
+			ContentEncoding: d.ContentEncoding,
+			DeliveryMode:    d.DeliveryMode,
+			Priority:        d.Priority,
+			CorrelationId:   d.CorrelationId,
+			ReplyTo:         d.ReplyTo,
+			MessageId:       d.MessageId,
+			Timestamp:       d.Timestamp,
+			Type:            d.Type,
+			UserId:          d.UserId,
+			AppId:           d.AppId,
+			Body:            d.Body,
+		})
+		if perr != nil {
+			Logger.Printf("error dead-lettering undecodable delivery %d: %+v", d.DeliveryTag, perr)
+			if rerr := d.Reject(true); rerr != nil {
+				Logger.Printf("error rejecting undecodable delivery %d: %+v", d.DeliveryTag, rerr)
+			}
+			return
+		}
+
+		if aerr := d.Ack(false); aerr != nil {
+			Logger.Printf("error acknowledging dead-lettered delivery %d: %+v", d.DeliveryTag, aerr)
+		}
+	}
+}
+
+// TypedConsumer consumes a queue and decodes deliveries into values of type T
+// according to their ContentType.  Set the exported fields before calling
+// Consume.
+type TypedConsumer[T any] struct {
+	ch       *Channel
+	queue    string
+	consumer string
+
+	AutoAck   bool
+	Exclusive bool
+	Args      Table
+
+	// Types, when set, decodes each delivery into the type registered for its
+	// Delivery.Type, which must be assignable to T.  Deliveries with an
+	// unregistered Type are handled as decode failures.
+	Types *TypeRegistry
+
+	// OnDecodeError handles deliveries that cannot be decoded.  It defaults
+	// to RejectOnDecodeError, or to dropping the delivery when AutoAck is set.
+	OnDecodeError DecodeErrorHandler
+}
+
+// NewTypedConsumer returns a TypedConsumer for the given queue and consumer
+// tag on ch.
+func NewTypedConsumer[T any](ch *Channel, queue, consumer string) *TypedConsumer[T] {
+	return &TypedConsumer[T]{
+		ch:       ch,
+		queue:    queue,
+		consumer: consumer,
+	}
+}
+
+// Consume starts consuming with Channel.ConsumeWithContext and returns the
+// decoded deliveries.  Deliveries that cannot be decoded are passed to
+// OnDecodeError instead.  The returned channel is closed when the underlying
+// deliveries channel is closed or ctx is done.  Once ctx is done, the consumer
+// is cancelled and deliveries not handed out yet, including those still
+// buffered, are requeued with Delivery.Nack without being passed to
+// OnDecodeError, unless AutoAck is set.
+func (c *TypedConsumer[T]) Consume(ctx context.Context) (<-chan TypedDelivery[T], error) {
+	deliveries, err := c.ch.ConsumeWithContext(ctx, c.queue, c.consumer, c.AutoAck, c.Exclusive, false, false, c.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	onError := c.OnDecodeError
+	if onError == nil {
+		onError = RejectOnDecodeError
+		if c.AutoAck {
+			onError = func(d Delivery, err error) {
+				Logger.Printf("dropping undecodable delivery %d: %+v", d.DeliveryTag, err)
+			}
+		}
+	}
+
+	requeue := func(d Delivery) {
+		if c.AutoAck {
+			return
+		}
+		if err := d.Nack(false, true); err != nil {
+			Logger.Printf("error requeueing delivery %d after the context was done: %+v", d.DeliveryTag, err)
+		}
+	}
+
+	out := make(chan TypedDelivery[T])
+	go func() {
+		defer func() {
+			close(out)
+			// Requeue deliveries buffered until the cancellation by
+			// ConsumeWithContext closes deliveries.
+			for d := range deliveries {
+				requeue(d)
+			}
+		}()
+		for {
+			var d Delivery
+			select {
+			case <-ctx.Done():
+				return
+			case next, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				d = next
+			}
+			if ctx.Err() != nil {
+				requeue(d)
+				return
+			}
+
+			v, err := c.decode(d)
+			if err != nil {
+				onError(d, err)
+				continue
+			}
+			select {
+			case out <- TypedDelivery[T]{Delivery: d, Message: v}:
+			case <-ctx.Done():
+				requeue(d)
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// decode unmarshals the delivery's body into a T.
+func (c *TypedConsumer[T]) decode(d Delivery) (T, error) {
+	var v T
+
+	s, ok := LookupSerializer(d.ContentType)
+	if !ok {
+		return v, fmt.Errorf("amqp: no serializer registered for content type %q", d.ContentType)
+	}
+
+	if c.Types == nil {
+		if err := s.Unmarshal(d.Body, &v); err != nil {
+			return v, fmt.Errorf("amqp: decoding %T from %q: %w", v, d.ContentType, err)
+		}
+		return v, nil
+	}
+
+	decoded, err := c.Types.decode(d.Type, s, d.Body)
+	if err != nil {
+		return v, err
+	}
+
+	v, ok = decoded.(T)
+	if !ok {
+		return v, fmt.Errorf("amqp: message type %q decodes to %T, which is not a %s", d.Type, decoded, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return v, nil
+}
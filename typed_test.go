@@ -0,0 +1,292 @@
+// Copyright (c) 2026 Broadcom. All Rights Reserved.
+// The term “Broadcom” refers to Broadcom Inc. and/or its subsidiaries. All rights reserved.
+
+package amqp091
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type orderPlaced struct {
+	ID    string `json:"id"`
+	Total int    `json:"total"`
+}
+
+type orderCancelled struct {
+	ID string `json:"id"`
+}
+
+type recordingAcknowledger struct {
+	acks    []uint64
+	rejects []uint64
+	requeue []bool
+}
+
+func (r *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	r.acks = append(r.acks, tag)
+	return nil
+}
+
+func (r *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	return nil
+}
+
+func (r *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	r.rejects = append(r.rejects, tag)
+	r.requeue = append(r.requeue, requeue)
+	return nil
+}
+
+func TestTypedConsumerDecodesByContentType(t *testing.T) {
+	c := NewTypedConsumer[orderPlaced](nil, "orders", "")
+
+	for _, contentType := range []string{ContentTypeJSON, ""} {
+		v, err := c.decode(Delivery{ContentType: contentType, Body: []byte(`{"id":"o-1","total":42}`)})
+		if err != nil {
+			t.Fatalf("unexpected error decoding %q: %v", contentType, err)
+		}
+		if v != (orderPlaced{ID: "o-1", Total: 42}) {
+			t.Errorf("unexpected decoded value: %+v", v)
+		}
+	}
+
+	if _, err := c.decode(Delivery{ContentType: "application/x-unknown", Body: []byte("{}")}); err == nil {
+		t.Error("expected an error for a content type without serializer")
+	}
+
+	if _, err := c.decode(Delivery{ContentType: ContentTypeJSON, Body: []byte("not json")}); err == nil {
+		t.Error("expected an error for an invalid body")
+	}
+}
+
+func TestTypedConsumerDispatchesOnType(t *testing.T) {
+	types := NewTypeRegistry()
+	RegisterType[orderPlaced](types, "order.placed")
+	RegisterType[orderCancelled](types, "order.cancelled")
+
+	c := NewTypedConsumer[any](nil, "orders", "")
+	c.Types = types
+
+	placed, err := c.decode(Delivery{Type: "order.placed", Body: []byte(`{"id":"o-1","total":42}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := placed.(orderPlaced); !ok {
+		t.Errorf("expected an orderPlaced, got %T", placed)
+	}
+
+	cancelled, err := c.decode(Delivery{Type: "order.cancelled", Body: []byte(`{"id":"o-1"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cancelled.(orderCancelled); !ok {
+		t.Errorf("expected an orderCancelled, got %T", cancelled)
+	}
+
+	if _, err := c.decode(Delivery{Type: "order.shipped", Body: []byte(`{}`)}); err == nil {
+		t.Error("expected an error for an unregistered message type")
+	}
+
+	narrow := NewTypedConsumer[orderPlaced](nil, "orders", "")
+	narrow.Types = types
+	if _, err := narrow.decode(Delivery{Type: "order.cancelled", Body: []byte(`{"id":"o-1"}`)}); err == nil {
+		t.Error("expected an error for a registered type not assignable to the consumer's type")
+	}
+}
+
+func TestTypedPublisherAndConsumer(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	published := make(chan *basicPublish, 1)
+	rejected := make(chan *basicReject, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Properties: properties{Type: "order.placed"}, Body: []byte("garbage")})
+
+		reject := &basicReject{}
+		srv.recv(1, reject)
+		rejected <- reject
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2, Properties: pub.Properties, Body: pub.Body})
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	types := NewTypeRegistry()
+	RegisterType[orderPlaced](types, "order.placed")
+
+	publisher := NewTypedPublisher[any](ch, "", "orders")
+	publisher.Types = types
+
+	if _, err := publisher.Publish(context.TODO(), orderCancelled{ID: "o-1"}, Publishing{}); err == nil {
+		t.Error("expected an error publishing an unregistered type")
+	}
+
+	if _, err := publisher.Publish(context.TODO(), orderPlaced{ID: "o-1", Total: 42}, Publishing{MessageId: "m-1"}); err != nil {
+		t.Fatalf("publish error: %v", err)
+	}
+
+	pub := <-published
+	if pub.Properties.ContentType != ContentTypeJSON || pub.Properties.Type != "order.placed" || pub.Properties.MessageId != "m-1" {
+		t.Errorf("unexpected publishing properties: %+v", pub.Properties)
+	}
+
+	consumer := NewTypedConsumer[any](ch, "orders", tag)
+	consumer.Types = types
+
+	deliveries, err := consumer.Consume(context.TODO())
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	if reject := <-rejected; reject.DeliveryTag != 1 || reject.Requeue {
+		t.Errorf("expected undecodable delivery 1 to be rejected without requeue, got: %+v", reject)
+	}
+
+	d := <-deliveries
+	if d.DeliveryTag != 2 || d.Message != (orderPlaced{ID: "o-1", Total: 42}) {
+		t.Errorf("unexpected delivery %d with message %+v", d.DeliveryTag, d.Message)
+	}
+}
+
+func TestTypedConsumerStopsDecodingWhenContextDone(t *testing.T) {
+	const tag = "consumer-tag"
+
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	cancelled := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		srv.recv(1, &basicConsume{})
+		srv.send(1, &basicConsumeOk{ConsumerTag: tag})
+
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 1, Body: []byte(`{"id":"o-1"}`)})
+
+		// Delivered after ctx is done but before the cancellation, it must
+		// be neither handed out nor rejected, but requeued.
+		<-cancelled
+		srv.recv(1, &basicCancel{})
+		srv.send(1, &basicDeliver{ConsumerTag: tag, DeliveryTag: 2, Body: []byte("garbage")})
+		srv.send(1, &basicCancelOk{ConsumerTag: tag})
+
+		nack := &basicNack{}
+		srv.recv(1, nack)
+		if nack.DeliveryTag != 2 || nack.Multiple || !nack.Requeue {
+			t.Errorf("expected the undelivered delivery to be requeued, got: %+v", nack)
+		}
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deliveries, err := NewTypedConsumer[orderPlaced](ch, "orders", tag).Consume(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error during consume: %v", err)
+	}
+
+	if d := <-deliveries; d.DeliveryTag != 1 || d.Message.ID != "o-1" {
+		t.Errorf("unexpected delivery %d with message %+v", d.DeliveryTag, d.Message)
+	}
+
+	cancel()
+	close(cancelled)
+
+	if d, ok := <-deliveries; ok {
+		t.Errorf("expected no delivery once ctx is done, got: %d", d.DeliveryTag)
+	}
+
+	<-done
+}
+
+func TestDeadLetterOnDecodeError(t *testing.T) {
+	rwc, srv := newSession(t)
+	defer rwc.Close()
+
+	published := make(chan *basicPublish, 1)
+
+	go func() {
+		srv.connectionOpen()
+		srv.channelOpen(1)
+
+		pub := &basicPublish{}
+		srv.recv(1, pub)
+		published <- pub
+	}()
+
+	c, err := Open(rwc, defaultConfig())
+	if err != nil {
+		t.Fatalf("could not create connection: %v (%s)", c, err)
+	}
+
+	ch, err := c.Channel()
+	if err != nil {
+		t.Fatalf("could not open channel: %v (%s)", ch, err)
+	}
+
+	ack := &recordingAcknowledger{}
+	handler := DeadLetterOnDecodeError(ch, "dlx", "orders.invalid")
+	handler(Delivery{
+		Acknowledger: ack,
+		DeliveryTag:  7,
+		Headers:      Table{"trace": "abc"},
+		Body:         []byte("garbage"),
+	}, errDecodeTest)
+
+	pub := <-published
+	if pub.Exchange != "dlx" || pub.RoutingKey != "orders.invalid" || string(pub.Body) != "garbage" {
+		t.Errorf("unexpected dead-lettered publishing: %+v", pub)
+	}
+	if got, _ := pub.Properties.Headers[DecodeErrorHeader].(string); !strings.Contains(got, "bad body") {
+		t.Errorf("expected the decode error header, got: %v", pub.Properties.Headers)
+	}
+	if pub.Properties.Headers["trace"] != "abc" {
+		t.Errorf("expected the original headers to be kept, got: %v", pub.Properties.Headers)
+	}
+
+	if len(ack.acks) != 1 || ack.acks[0] != 7 {
+		t.Errorf("expected delivery 7 to be acknowledged, got: %v", ack.acks)
+	}
+}
+
+var errDecodeTest = errors.New("bad body")
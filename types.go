@@ -6,6 +6,7 @@
 package amqp091
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"sync/atomic"
@@ -72,6 +73,17 @@ var (
 	// declared size exceeds the frame_max negotiated during connection.tune.
 	ErrFrameTooLarge = &Error{Code: FrameError, Reason: "frame size exceeds negotiated frame_max"}
 
+	// ErrMessageTooLarge is returned when a message body exceeds
+	// Config.MaxMessageSize, either on publish or when the server announces a
+	// larger body in a content header, and by PublishSpool.Publish for a
+	// publishing too large to be spooled.
+	ErrMessageTooLarge = &Error{Code: ContentTooLarge, Reason: "message size exceeds configured maximum"}
+
+	// ErrFlowTimeout is returned by publishing methods without a context when
+	// the server paused the channel's flow with channel.flow and did not
+	// resume it within Config.FlowControlTimeout.
+	ErrFlowTimeout = &Error{Code: ResourceError, Reason: "timed out waiting for the server to resume channel flow"}
+
 	// ErrCommandInvalid is returned when the server sends an unexpected response
 	// to this requested message type. This indicates a bug in this client.
 	ErrCommandInvalid = &Error{Code: CommandInvalid, Reason: "unexpected command received"}
@@ -265,6 +277,26 @@ type Publishing struct {
 	Body []byte
 }
 
+// properties converts the Publishing fields into the content header
+// properties sent on the wire.
+func (msg Publishing) properties() properties {
+	return properties{
+		Headers:         msg.Headers,
+		ContentType:     msg.ContentType,
+		ContentEncoding: msg.ContentEncoding,
+		DeliveryMode:    msg.DeliveryMode,
+		Priority:        msg.Priority,
+		CorrelationId:   msg.CorrelationId,
+		ReplyTo:         msg.ReplyTo,
+		Expiration:      msg.Expiration,
+		MessageId:       msg.MessageId,
+		Timestamp:       msg.Timestamp,
+		Type:            msg.Type,
+		UserId:          msg.UserId,
+		AppId:           msg.AppId,
+	}
+}
+
 // Blocking notifies the server's TCP flow control of the Connection.  When a
 // server hits a memory or disk alarm it will block all connections until the
 // resources are reclaimed.  Use NotifyBlock on the Connection to receive these
@@ -280,8 +312,9 @@ type Blocking struct {
 type DeferredConfirmation struct {
 	DeliveryTag uint64
 
-	done chan struct{}
-	ack  bool
+	done     chan struct{}
+	ack      bool
+	returned *Return
 }
 
 // Confirmation notifies the acknowledgment or negative acknowledgement of a
@@ -359,6 +392,22 @@ const (
 	// ConsumerTimeoutArg is available in RabbitMQ 3.12+ as a queue argument.
 	ConsumerTimeoutArg      = "x-consumer-timeout"
 	SingleActiveConsumerArg = "x-single-active-consumer"
+	// QueueDeadLetterExchangeArg and QueueDeadLetterRoutingKeyArg route the
+	// messages dead-lettered from the queue.  See [Channel.QueueDeclare].
+	QueueDeadLetterExchangeArg   = "x-dead-letter-exchange"
+	QueueDeadLetterRoutingKeyArg = "x-dead-letter-routing-key"
+	// QueueDeadLetterStrategyArg is only supported by quorum queues.  Accepted
+	// values are [QueueDeadLetterAtMostOnce] (default) and
+	// [QueueDeadLetterAtLeastOnce].
+	QueueDeadLetterStrategyArg = "x-dead-letter-strategy"
+	// QueueMaxPriorityArg makes a classic queue a priority queue.
+	QueueMaxPriorityArg = "x-max-priority"
+	// QueueDeliveryLimitArg and QueueInitialGroupSizeArg are only supported
+	// by quorum queues.
+	QueueDeliveryLimitArg    = "x-delivery-limit"
+	QueueInitialGroupSizeArg = "x-quorum-initial-group-size"
+	// StreamInitialClusterSizeArg is only supported by streams.
+	StreamInitialClusterSizeArg = "x-initial-cluster-size"
 )
 
 // Values for queue arguments. Use as values for queue arguments during queue declaration.
@@ -379,6 +428,8 @@ const (
 	QueueOverflowDropHead         = "drop-head"
 	QueueOverflowRejectPublish    = "reject-publish"
 	QueueOverflowRejectPublishDLX = "reject-publish-dlx"
+	QueueDeadLetterAtMostOnce     = "at-most-once"
+	QueueDeadLetterAtLeastOnce    = "at-least-once"
 )
 
 // Table stores user supplied fields of the following types:
@@ -443,6 +494,35 @@ func (t Table) Validate() error {
 	return validateField(t)
 }
 
+// MarshalTable encodes t as an AMQP field table, as in the arguments and headers
+// of the methods sent to the server.  Unlike encodings such as JSON, it keeps the
+// AMQP type of each field, so UnmarshalTable returns the table the server would
+// receive.
+func MarshalTable(t Table) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeTable(&buf, t); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTable decodes a field table encoded by MarshalTable.
+func UnmarshalTable(data []byte) (Table, error) {
+	r := bytes.NewReader(data)
+	t, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("amqp: %d bytes after the field table", r.Len())
+	}
+	return t, nil
+}
+
 // Sets the connection name property. This property can be used in
 // amqp.Config to set a custom connection name during amqp.DialConfig(). This
 // can be helpful to identify specific connections in RabbitMQ, for debugging or
@@ -2,6 +2,7 @@ package amqp091
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -106,3 +107,36 @@ func TestValidateField(t *testing.T) {
 		t.Error("validateField should fail for unsupported type but it didn't")
 	}
 }
+
+func TestMarshalTableKeepsFieldTypes(t *testing.T) {
+	table := Table{
+		"int":     int32(1),
+		"long":    int64(1),
+		"float":   float32(1),
+		"double":  2.0,
+		"bytes":   []byte("raw"),
+		"time":    time.Unix(1700000000, 0),
+		"decimal": Decimal{Scale: 2, Value: 1999},
+		"nested":  Table{"array": []any{"a", int16(1)}},
+		"null":    nil,
+	}
+
+	b, err := MarshalTable(table)
+	if err != nil {
+		t.Fatalf("could not marshal table: %v", err)
+	}
+	got, err := UnmarshalTable(b)
+	if err != nil {
+		t.Fatalf("could not unmarshal table: %v", err)
+	}
+	if !reflect.DeepEqual(got, table) {
+		t.Errorf("expected %#v, got: %#v", table, got)
+	}
+
+	if _, err := MarshalTable(Table{"unsupported": struct{}{}}); err == nil {
+		t.Error("expected an error for an unsupported field type")
+	}
+	if _, err := UnmarshalTable(append(b, 0)); err == nil {
+		t.Error("expected an error for trailing data")
+	}
+}